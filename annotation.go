@@ -0,0 +1,95 @@
+package diff2html
+
+import (
+	"html/template"
+	"io"
+	"sort"
+	"time"
+)
+
+// Annotation is a review comment shown below a line of the diff.
+type Annotation struct {
+	// Path is the old or new name of the file.
+	Path string
+	// Side and Line select the line by its old or new line number. The
+	// zero Side is SideNew.
+	Side   Side
+	Line   int
+	Author string
+	// Body is rendered as markdown; raw html is escaped.
+	Body string
+	Time time.Time
+}
+
+var (
+	annotationTemplate = template.Must(template.New("annotation").Parse(annotation))
+)
+
+// fileAnnotations holds the annotations of one file by side and line.
+type fileAnnotations map[Side]map[int][]Annotation
+
+// annotationsOf picks the annotations of file, each line's in time order.
+func annotationsOf(file *File, annotations []Annotation) fileAnnotations {
+	if len(annotations) == 0 {
+		return nil
+	}
+	notes := fileAnnotations{}
+	for _, a := range annotations {
+		path := unifyPath(a.Path)
+		if path != unifyPath(file.NewName) && path != unifyPath(file.OldName) {
+			continue
+		}
+		if a.Side == "" {
+			a.Side = SideNew
+		}
+		if notes[a.Side] == nil {
+			notes[a.Side] = map[int][]Annotation{}
+		}
+		notes[a.Side][a.Line] = append(notes[a.Side][a.Line], a)
+	}
+	for _, lines := range notes {
+		for _, list := range lines {
+			sort.SliceStable(list, func(i, j int) bool {
+				return list[i].Time.Before(list[j].Time)
+			})
+		}
+	}
+	return notes
+}
+
+// at returns the annotations of the row showing old line oldNumber and
+// new line newNumber; a zero number means the row has no such line.
+func (n fileAnnotations) at(oldNumber, newNumber int) []Annotation {
+	list := []Annotation{}
+	if oldNumber > 0 {
+		list = append(list, n[SideOld][oldNumber]...)
+	}
+	if newNumber > 0 {
+		list = append(list, n[SideNew][newNumber]...)
+	}
+	return list
+}
+
+// writeAnnotationHTML writes a row holding the comment a. A placeholder
+// row keeps the comment's height but hides it, so the other table of
+// the side-by-side layout stays aligned.
+func writeAnnotationHTML(w io.Writer, a Annotation, placeholder bool) error {
+	datetime, timeText := "", ""
+	if !a.Time.IsZero() {
+		datetime = a.Time.Format(time.RFC3339)
+		timeText = a.Time.Format("2006-01-02 15:04")
+	}
+	return annotationTemplate.Execute(w, struct {
+		Placeholder bool
+		Author      string
+		Datetime    string
+		Time        string
+		Body        template.HTML
+	}{
+		Placeholder: placeholder,
+		Author:      a.Author,
+		Datetime:    datetime,
+		Time:        timeText,
+		Body:        renderMarkdown(a.Body),
+	})
+}
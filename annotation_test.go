@@ -0,0 +1,95 @@
+package diff2html
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const annotatedDiff = "diff --git a/main.go b/main.go\n" +
+	"index 1a2b3c4..5d6e7f8 100644\n" +
+	"--- a/main.go\n" +
+	"+++ b/main.go\n" +
+	"@@ -1,3 +1,3 @@\n" +
+	" package main\n" +
+	"-var a = 1\n" +
+	"+var a = 2\n" +
+	" var b = 3\n"
+
+func Test_annotationsOf(t *testing.T) {
+	file := &File{OldName: "main.go", NewName: "main.go"}
+	early := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	notes := annotationsOf(file, []Annotation{
+		{Path: "main.go", Side: SideNew, Line: 2, Body: "second", Time: early.Add(time.Hour)},
+		{Path: "other.go", Side: SideNew, Line: 2, Body: "other"},
+		{Path: "main.go", Side: SideNew, Line: 2, Body: "first", Time: early},
+		{Path: "main.go", Side: SideOld, Line: 2, Body: "old"},
+		{Path: "main.go", Line: 2, Body: "unsided", Time: early.Add(2 * time.Hour)},
+	})
+
+	got := []string{}
+	for _, a := range notes.at(2, 2) {
+		got = append(got, a.Body)
+	}
+	if strings.Join(got, ",") != "old,first,second,unsided" {
+		t.Errorf("got %v", got)
+	}
+	if len(notes.at(0, 2)) != 3 {
+		t.Errorf("old line 0 should not match")
+	}
+	if annotationsOf(file, nil) != nil {
+		t.Errorf("no annotations should give nil")
+	}
+}
+
+func TestRender_annotations(t *testing.T) {
+	files, err := Parse(annotatedDiff, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations := []Annotation{
+		{Path: "main.go", Side: SideOld, Line: 2, Author: "ana", Body: "why <1>?", Time: time.Date(2020, 5, 17, 9, 30, 0, 0, time.UTC)},
+		{Path: "main.go", Side: SideNew, Line: 3, Author: "bo", Body: "[ok](javascript:x)"},
+	}
+
+	html, err := Render(files, RenderConfig{OutputFormat: SideBySide, Annotations: annotations})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sides := strings.Split(html, `<div class="d2h-file-side-diff">`)
+	if len(sides) != 3 {
+		t.Fatalf("expected two tables, got %d", len(sides)-1)
+	}
+	left, right := sides[1], sides[2]
+	for _, side := range []string{left, right} {
+		if got := strings.Count(side, `<tr class="d2h-annotation`); got != 2 {
+			t.Errorf("expected 2 comment rows on each side, got %d", got)
+		}
+		if got := strings.Count(side, "d2h-annotation-placeholder"); got != 1 {
+			t.Errorf("expected 1 placeholder on each side, got %d", got)
+		}
+	}
+	if !strings.Contains(left, `<tr class="d2h-annotation">`) || strings.Index(left, "why &lt;1&gt;?") > strings.Index(left, "d2h-annotation-placeholder") {
+		t.Errorf("the old-side comment should be shown on the left first:\n%s", left)
+	}
+	if !strings.Contains(html, `<time datetime="2020-05-17T09:30:00Z">2020-05-17 09:30</time>`) {
+		t.Errorf("missing time:\n%s", html)
+	}
+	if strings.Contains(html, "javascript:") {
+		t.Errorf("unsafe link rendered:\n%s", html)
+	}
+
+	html, err = Render(files, RenderConfig{OutputFormat: LineByLine, Annotations: annotations})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(html, "d2h-annotation-placeholder") {
+		t.Errorf("line by line needs no placeholders:\n%s", html)
+	}
+	deleted := strings.Index(html, `id="d2h-main-go-L2"`)
+	comment := strings.Index(html, "why &lt;1&gt;?")
+	inserted := strings.Index(html, `id="d2h-main-go-R2"`)
+	if !(deleted < comment && comment < inserted) {
+		t.Errorf("comment should follow its line:\n%s", html)
+	}
+}
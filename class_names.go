@@ -0,0 +1,69 @@
+package diff2html
+
+// ClassNames maps line types to the CSS classes of the html output, so
+// the diff can be styled with another stylesheet. Empty fields fall back
+// to DefaultClassNames.
+type ClassNames struct {
+	Context string
+	Insert  string
+	Delete  string
+	// InsertChange and DeleteChange are used for lines that are paired
+	// with a line on the other side and highlighted word by word.
+	InsertChange string
+	DeleteChange string
+	// Info is used for the hunk header rows.
+	Info string
+}
+
+// DefaultClassNames returns the classes used by the bundled stylesheet.
+func DefaultClassNames() ClassNames {
+	return ClassNames{
+		Context:      "d2h-cntx",
+		Insert:       "d2h-ins",
+		Delete:       "d2h-del",
+		InsertChange: "d2h-ins d2h-change",
+		DeleteChange: "d2h-del d2h-change",
+		Info:         "d2h-info",
+	}
+}
+
+func (c ClassNames) withDefaults() ClassNames {
+	d := DefaultClassNames()
+	if c.Context == "" {
+		c.Context = d.Context
+	}
+	if c.Insert == "" {
+		c.Insert = d.Insert
+	}
+	if c.Delete == "" {
+		c.Delete = d.Delete
+	}
+	if c.InsertChange == "" {
+		c.InsertChange = d.InsertChange
+	}
+	if c.DeleteChange == "" {
+		c.DeleteChange = d.DeleteChange
+	}
+	if c.Info == "" {
+		c.Info = d.Info
+	}
+	return c
+}
+
+// line returns the class of a line of type t. changed tells whether the
+// line is highlighted against a paired line.
+func (c ClassNames) line(t LineType, changed bool) string {
+	switch t {
+	case LineInsert:
+		if changed {
+			return c.InsertChange
+		}
+		return c.Insert
+	case LineDelete:
+		if changed {
+			return c.DeleteChange
+		}
+		return c.Delete
+	}
+	return c.Context
+}
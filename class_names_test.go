@@ -0,0 +1,62 @@
+package diff2html
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassNames_line(t *testing.T) {
+	c := ClassNames{Insert: "bg-green-100"}.withDefaults()
+	tests := []struct {
+		lineType LineType
+		changed  bool
+		want     string
+	}{
+		{LineContext, false, "d2h-cntx"},
+		{LineInsert, false, "bg-green-100"},
+		{LineInsert, true, "d2h-ins d2h-change"},
+		{LineDelete, false, "d2h-del"},
+		{LineDelete, true, "d2h-del d2h-change"},
+	}
+	for _, tt := range tests {
+		if got := c.line(tt.lineType, tt.changed); got != tt.want {
+			t.Errorf("line(%s, %v) = %q, want %q", tt.lineType, tt.changed, got, tt.want)
+		}
+	}
+}
+
+func TestRender_classNames(t *testing.T) {
+	input := "--- a/sample.go\n" +
+		"+++ b/sample.go\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		" package sample\n" +
+		"-var a = 1\n" +
+		"+var a = 2\n"
+	files, err := Parse(input, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []OutputFormat{SideBySide, LineByLine} {
+		html, err := Render(files, RenderConfig{
+			OutputFormat: format,
+			ClassNames: ClassNames{
+				Context:      "ctx",
+				InsertChange: "ins-change",
+				DeleteChange: "del-change",
+				Info:         "hunk",
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, class := range []string{`class="ctx"`, `class="ins-change"`, `class="del-change"`, `class="hunk"`} {
+			if !strings.Contains(html, class) {
+				t.Errorf("%s: missing %s", format, class)
+			}
+		}
+		if strings.Contains(html, "d2h-cntx") || strings.Contains(html, "d2h-info") {
+			t.Errorf("%s: default classes left in output", format)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Command diff2html converts a unified or git diff into an html page.
+//
+//	git diff | diff2html > review.html
+//	diff2html -layout line-by-line -include '*.go' -o review.html changes.diff
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	diff2html "github.com/yu-ichiko/go-diff2html"
+)
+
+type patterns []string
+
+func (p *patterns) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *patterns) Set(value string) error {
+	if _, err := path.Match(value, ""); err != nil {
+		return err
+	}
+	*p = append(*p, value)
+	return nil
+}
+
+type options struct {
+	output       string
+	title        string
+	layout       string
+	style        string
+	whitespace   string
+	hideSpace    bool
+	normalizeEOL bool
+	syntax       bool
+	summary      bool
+	collapse     int
+	context      int
+	includes     patterns
+	excludes     patterns
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "diff2html:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	opts := options{}
+	fs := flag.NewFlagSet("diff2html", flag.ContinueOnError)
+	fs.StringVar(&opts.output, "o", "", "write the html to `file` instead of stdout")
+	fs.StringVar(&opts.title, "title", "diff", "page `title`")
+	fs.StringVar(&opts.layout, "layout", string(diff2html.SideBySide), "side-by-side or line-by-line")
+	fs.StringVar(&opts.style, "diff-style", string(diff2html.DiffStyleWord), "highlight changed lines by word or char")
+	fs.StringVar(&opts.whitespace, "whitespace", string(diff2html.IgnoreWhitespaceNone), "ignore `mode` whitespace differences: none, all, change, trailing or cr-at-eol")
+	fs.BoolVar(&opts.hideSpace, "hide-whitespace", false, "show lines that differ only in ignored whitespace as unchanged")
+	fs.BoolVar(&opts.normalizeEOL, "normalize-eol", false, "split lines at lone carriage returns and drop those of CRLF line endings")
+	fs.BoolVar(&opts.summary, "summary", false, "list the changed files above the diff")
+	fs.BoolVar(&opts.syntax, "syntax", false, "syntax highlight the code")
+	fs.IntVar(&opts.context, "context", 0, "show `n` more unchanged lines around each hunk, read from the files in the current directory (-1 for whole files)")
+	fs.IntVar(&opts.collapse, "collapse", 0, "collapse runs of more than `n` unchanged lines")
+	fs.Var(&opts.includes, "include", "only show files matching `pattern` (repeatable)")
+	fs.Var(&opts.excludes, "exclude", "hide files matching `pattern` (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: diff2html [flags] [file]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("too many arguments")
+	}
+
+	in := stdin
+	if name := fs.Arg(0); name != "" && name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	files := []*diff2html.File{}
+	err := diff2html.ParseReader(in, diff2html.Config{NormalizeLineEndings: opts.normalizeEOL}, func(file *diff2html.File) error {
+		if opts.match(file) {
+			files = append(files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.output == "" {
+		return writeOutput(stdout, files, opts)
+	}
+	f, err := os.Create(opts.output)
+	if err != nil {
+		return err
+	}
+	if err := writeOutput(f, files, opts); err != nil {
+		f.Close()
+		return err
+	}
+	// Close reports a failed write the file system deferred.
+	return f.Close()
+}
+
+// writeOutput writes the page to out through a buffer.
+func writeOutput(out io.Writer, files []*diff2html.File, opts options) error {
+	w := bufio.NewWriter(out)
+	if err := writePage(w, files, opts); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// match reports whether file passes the include and exclude filters.
+func (opts options) match(file *diff2html.File) bool {
+	if len(opts.includes) > 0 && !matchAny(opts.includes, file) {
+		return false
+	}
+	return !matchAny(opts.excludes, file)
+}
+
+// matchAny matches the old and new path, and their base names, of file.
+func matchAny(patterns []string, file *diff2html.File) bool {
+	for _, pattern := range patterns {
+		for _, name := range []string{file.NewName, file.OldName} {
+			if name == "" {
+				continue
+			}
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+			if ok, _ := path.Match(pattern, path.Base(name)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func writePage(w io.Writer, files []*diff2html.File, opts options) error {
+	conf := diff2html.RenderConfig{
+		OutputFormat:        diff2html.OutputFormat(opts.layout),
+		DiffStyle:           diff2html.DiffStyle(opts.style),
+		IgnoreWhitespace:    diff2html.IgnoreWhitespace(opts.whitespace),
+		WhitespaceAsContext: opts.hideSpace,
+		Document:            true,
+		Title:               opts.title,
+		Summary:             opts.summary,
+		CollapseContext:     opts.collapse,
+		ExpandContext:       opts.context,
+	}
+	if opts.context != 0 {
+		conf.Source = diff2html.FSSource(nil, os.DirFS("."))
+	}
+	if opts.syntax {
+		conf.SyntaxHighlighter = diff2html.BasicHighlighter{}
+	}
+	return diff2html.RenderTo(w, files, conf)
+}
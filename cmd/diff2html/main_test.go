@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const input = "diff --git a/main.go b/main.go\n" +
+	"--- a/main.go\n" +
+	"+++ b/main.go\n" +
+	"@@ -1 +1 @@\n" +
+	"-package foo\n" +
+	"+package main\n" +
+	"diff --git a/docs/README.md b/docs/README.md\n" +
+	"--- a/docs/README.md\n" +
+	"+++ b/docs/README.md\n" +
+	"@@ -1 +1 @@\n" +
+	"-# foo\n" +
+	"+# main\n"
+
+func Test_run(t *testing.T) {
+	out := &bytes.Buffer{}
+	if err := run([]string{"-title", "<review>"}, strings.NewReader(input), out); err != nil {
+		t.Fatal(err)
+	}
+
+	page := out.String()
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<title>&lt;review&gt;</title>",
+		".d2h-wrapper",
+		`class="d2h-file-side-diff"`,
+		"main.go",
+		"docs/README.md",
+		"</html>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
+
+func Test_run_filters(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+		skip []string
+	}{
+		{[]string{"-include", "*.go"}, []string{"main.go"}, []string{"README.md"}},
+		{[]string{"-exclude", "docs/*"}, []string{"main.go"}, []string{"README.md"}},
+		{[]string{"-include", "*.md", "-include", "*.go", "-exclude", "main.go"}, []string{"README.md"}, []string{"main.go"}},
+	}
+
+	for _, tt := range tests {
+		out := &bytes.Buffer{}
+		if err := run(tt.args, strings.NewReader(input), out); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range tt.want {
+			if !strings.Contains(out.String(), name) {
+				t.Errorf("%v: missing %s", tt.args, name)
+			}
+		}
+		for _, name := range tt.skip {
+			if strings.Contains(out.String(), name) {
+				t.Errorf("%v: unexpected %s", tt.args, name)
+			}
+		}
+	}
+}
+
+func Test_run_layout(t *testing.T) {
+	out := &bytes.Buffer{}
+	if err := run([]string{"-layout", "line-by-line", "-diff-style", "char"}, strings.NewReader(input), out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), `class="d2h-file-side-diff"`) {
+		t.Error("expected the line-by-line layout")
+	}
+
+	if err := run([]string{"-layout", "columns"}, strings.NewReader(input), &bytes.Buffer{}); err == nil {
+		t.Error("expected an error for an unknown layout")
+	}
+}
+
+func Test_run_syntax(t *testing.T) {
+	out := &bytes.Buffer{}
+	if err := run([]string{"-syntax"}, strings.NewReader(input), out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `<span class="d2h-syntax-keyword">`) {
+		t.Error("expected syntax highlighted code")
+	}
+}
+
+func Test_run_collapse(t *testing.T) {
+	input := "--- a/notes.txt\n+++ b/notes.txt\n@@ -1,10 +1,10 @@\n-a\n+b\n" + strings.Repeat(" x\n", 9)
+	out := &bytes.Buffer{}
+	if err := run([]string{"-collapse", "4"}, strings.NewReader(input), out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "show 6 hidden lines") {
+		t.Error("expected collapsed context")
+	}
+}
+
+func Test_run_context(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("one\ntwo\nthree\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	input := "--- a/notes.txt\n+++ b/notes.txt\n@@ -2 +2 @@\n-2\n+two\n"
+	out := &bytes.Buffer{}
+	if err := run([]string{"-context", "-1"}, strings.NewReader(input), out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "three") {
+		t.Error("expected context from the working tree")
+	}
+}
+
+func Test_run_summary(t *testing.T) {
+	out := &bytes.Buffer{}
+	if err := run([]string{"-summary"}, strings.NewReader(input), out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "2 files changed") {
+		t.Error("expected a summary")
+	}
+}
+
+func Test_run_whitespace(t *testing.T) {
+	if err := run([]string{"-whitespace", "tabs"}, strings.NewReader(input), &bytes.Buffer{}); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+	if err := run([]string{"-whitespace", "change", "-hide-whitespace"}, strings.NewReader(input), &bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+}
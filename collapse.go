@@ -0,0 +1,126 @@
+package diff2html
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+)
+
+// CollapseStyle selects how collapsed context lines are expanded.
+type CollapseStyle string
+
+const (
+	// CollapseScript expands a collapsed run when its row is clicked,
+	// using a small script written after the diff.
+	CollapseScript CollapseStyle = "script"
+	// CollapseDetails needs no script: a <details> element above each
+	// file expands all of its collapsed runs at once.
+	CollapseDetails CollapseStyle = "details"
+)
+
+// collapseMargin is how many context lines next to a change stay visible
+// when the rest of their run is collapsed.
+const collapseMargin = 3
+
+var (
+	collapseOpenTemplate = template.Must(template.New("collapse-open").Parse(collapseOpen))
+	expandAllTemplate    = template.Must(template.New("expand-all").Parse(expandAll))
+)
+
+// collapsedRuns returns the runs of context lines of a block that are
+// hidden, as a map from the index of their first line to the index after
+// their last one. Runs of up to threshold lines are not collapsed.
+func collapsedRuns(lines []*Line, threshold int) map[int]int {
+	runs := map[int]int{}
+	if threshold <= 0 {
+		return runs
+	}
+	for start := 0; start < len(lines); {
+		if lines[start].Type != LineContext {
+			start++
+			continue
+		}
+		end := start
+		for end < len(lines) && lines[end].Type == LineContext {
+			end++
+		}
+		if end-start > threshold {
+			first, last := start, end
+			if start > 0 {
+				first += collapseMargin
+			}
+			if end < len(lines) {
+				last -= collapseMargin
+			}
+			if first < last {
+				runs[first] = last
+			}
+		}
+		start = end
+	}
+	return runs
+}
+
+// collapsedLines counts the hidden lines of file.
+func collapsedLines(file *File, threshold int) int {
+	n := 0
+	for _, block := range file.Blocks {
+		for start, end := range collapsedRuns(block.Lines, threshold) {
+			n += end - start
+		}
+	}
+	return n
+}
+
+// writeCollapseOpen writes the row that stands for a collapsed run and
+// starts the table body holding its lines.
+func writeCollapseOpen(w io.Writer, conf RenderConfig, id, hidden int, typ, lineClass, contentClass string) error {
+	return collapseOpenTemplate.Execute(w, struct {
+		ID           int
+		Hidden       int
+		Script       bool
+		Type         string
+		LineClass    string
+		ContentClass string
+	}{
+		ID:           id,
+		Hidden:       hidden,
+		Script:       conf.CollapseStyle != CollapseDetails,
+		Type:         typ,
+		LineClass:    lineClass,
+		ContentClass: contentClass,
+	})
+}
+
+// writeCollapseClose ends the table body started by writeCollapseOpen.
+func writeCollapseClose(w io.Writer) error {
+	_, err := io.WriteString(w, collapseClose)
+	return err
+}
+
+// makeExpandAllHTML returns the <details> element that expands the
+// collapsed runs of file with CollapseDetails.
+func makeExpandAllHTML(file *File, conf RenderConfig) (string, error) {
+	if conf.CollapseStyle != CollapseDetails {
+		return "", nil
+	}
+	hidden := collapsedLines(file, conf.CollapseContext)
+	if hidden == 0 {
+		return "", nil
+	}
+	buf := &bytes.Buffer{}
+	if err := expandAllTemplate.Execute(buf, struct{ Hidden int }{hidden}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// writeCollapseScript writes the script expanding collapsed runs, when
+// conf collapses them with CollapseScript.
+func writeCollapseScript(w io.Writer, conf RenderConfig) error {
+	if conf.CollapseContext <= 0 || conf.CollapseStyle == CollapseDetails {
+		return nil
+	}
+	_, err := io.WriteString(w, collapseScript)
+	return err
+}
@@ -0,0 +1,107 @@
+package diff2html
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func linesOf(types string) []*Line {
+	lines := []*Line{}
+	for _, c := range types {
+		switch c {
+		case ' ':
+			lines = append(lines, &Line{Type: LineContext})
+		case '+':
+			lines = append(lines, &Line{Type: LineInsert})
+		case '-':
+			lines = append(lines, &Line{Type: LineDelete})
+		}
+	}
+	return lines
+}
+
+func Test_collapsedRuns(t *testing.T) {
+	tests := []struct {
+		lines     string
+		threshold int
+		want      map[int]int
+	}{
+		{"          ", 0, map[int]int{}},
+		{"          ", 10, map[int]int{}},
+		{"          ", 9, map[int]int{0: 10}},
+		{"        -+", 5, map[int]int{0: 5}},
+		{"-+        ", 5, map[int]int{5: 10}},
+		{"-          +", 5, map[int]int{4: 8}},
+		{"-      +", 5, map[int]int{}},
+		{"-   +   -", 2, map[int]int{}},
+	}
+	for _, tt := range tests {
+		got := collapsedRuns(linesOf(tt.lines), tt.threshold)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("collapsedRuns(%q, %d) = %v, want %v", tt.lines, tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestRender_collapseContext(t *testing.T) {
+	input := "--- a/sample.txt\n" +
+		"+++ b/sample.txt\n" +
+		"@@ -1,12 +1,12 @@\n" +
+		"-a\n" +
+		"+b\n"
+	for i := 2; i <= 12; i++ {
+		input += " line\n"
+	}
+	files, err := Parse(input, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []OutputFormat{SideBySide, LineByLine} {
+		sides := 1
+		if format == SideBySide {
+			sides = 2
+		}
+
+		html, err := Render(files, RenderConfig{OutputFormat: format, CollapseContext: 5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := strings.Count(html, "⋯ show 8 hidden lines"); n != sides {
+			t.Errorf("%s: got %d expand rows, want %d", format, n, sides)
+		}
+		if n := strings.Count(html, `<tbody class="d2h-diff-tbody d2h-collapsed" data-d2h-collapse="1">`); n != sides {
+			t.Errorf("%s: got %d collapsed bodies, want %d", format, n, sides)
+		}
+		if strings.Count(html, "<tbody") != strings.Count(html, "</tbody>") {
+			t.Errorf("%s: unbalanced table bodies", format)
+		}
+		if strings.Count(html, "<script>") != 1 || strings.Contains(html, "<details") {
+			t.Errorf("%s: expected the expand script only", format)
+		}
+
+		html, err = Render(files, RenderConfig{OutputFormat: format, CollapseContext: 5, CollapseStyle: CollapseDetails})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := strings.Count(html, "⋯ 8 hidden lines"); n != sides {
+			t.Errorf("%s: got %d collapsed rows, want %d", format, n, sides)
+		}
+		if !strings.Contains(html, "<summary>Show 8 hidden lines</summary>") || strings.Contains(html, "<script>") {
+			t.Errorf("%s: expected a details element and no script", format)
+		}
+
+		html, err = Render(files, RenderConfig{OutputFormat: format})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(html, "d2h-collapsed") || strings.Contains(html, "<script>") {
+			t.Errorf("%s: collapsed without CollapseContext", format)
+		}
+	}
+
+	if _, err := Render(files, RenderConfig{CollapseStyle: "accordion"}); err == nil {
+		t.Error("expected an error for an unknown collapse style")
+	}
+}
@@ -0,0 +1,510 @@
+package diff2html
+
+// CSS is the stylesheet for the html generated by this package.
+const CSS = `/*
+ *
+ * Diff to HTML (diff2html.css)
+ * Author: rtfpessoa
+ *
+ */
+
+.d2h-wrapper {
+    text-align: left;
+}
+
+.d2h-file-header {
+    padding: 5px 10px;
+    border-bottom: 1px solid #d8d8d8;
+    background-color: #f7f7f7;
+}
+
+.d2h-file-stats {
+    display: -webkit-box;
+    display: -ms-flexbox;
+    display: flex;
+    margin-left: auto;
+    font-size: 14px;
+}
+
+.d2h-lines-added {
+    text-align: right;
+    border: 1px solid #b4e2b4;
+    border-radius: 5px 0 0 5px;
+    color: #399839;
+    padding: 2px;
+    vertical-align: middle;
+}
+
+.d2h-lines-deleted {
+    text-align: left;
+    border: 1px solid #e9aeae;
+    border-radius: 0 5px 5px 0;
+    color: #c33;
+    padding: 2px;
+    vertical-align: middle;
+    margin-left: 1px;
+}
+
+.d2h-file-name-wrapper {
+    display: -webkit-box;
+    display: -ms-flexbox;
+    display: flex;
+    -webkit-box-align: center;
+    -ms-flex-align: center;
+    align-items: center;
+    width: 100%;
+    font-family: "Source Sans Pro", "Helvetica Neue", Helvetica, Arial, sans-serif;
+    font-size: 15px;
+}
+
+.d2h-file-name {
+    white-space: nowrap;
+    text-overflow: ellipsis;
+    overflow-x: hidden;
+    line-height: 21px;
+}
+
+.d2h-file-wrapper {
+    border: 1px solid #ddd;
+    border-radius: 3px;
+    margin-bottom: 1em;
+}
+
+.d2h-diff-table {
+    width: 100%;
+    border-collapse: collapse;
+    font-family: "Menlo", "Consolas", monospace;
+    font-size: 13px;
+}
+
+.d2h-diff-tbody > tr > td {
+    height: 20px;
+    line-height: 20px;
+}
+
+.d2h-files-diff {
+    display: block;
+    width: 100%;
+    height: 100%;
+}
+
+.d2h-file-diff {
+    overflow-x: scroll;
+    overflow-y: hidden;
+}
+
+.d2h-file-side-diff {
+    display: inline-block;
+    overflow-x: scroll;
+    overflow-y: hidden;
+    width: 50%;
+    margin-right: -4px;
+    margin-bottom: -8px;
+}
+
+.d2h-code-line {
+    display: inline-block;
+    white-space: nowrap;
+    padding: 0 10px;
+    margin-left: 80px;
+}
+
+.d2h-code-side-line {
+    display: inline-block;
+    white-space: nowrap;
+    padding: 0 10px;
+    margin-left: 50px;
+}
+
+.d2h-code-line del,
+.d2h-code-side-line del {
+    display: inline-block;
+    margin-top: -1px;
+    text-decoration: none;
+    background-color: #ffb6ba;
+    border-radius: 0.2em;
+}
+
+.d2h-code-line ins,
+.d2h-code-side-line ins {
+    display: inline-block;
+    margin-top: -1px;
+    text-decoration: none;
+    background-color: #97f295;
+    border-radius: 0.2em;
+    text-align: left;
+}
+
+.d2h-code-line-prefix {
+    display: inline;
+    background: none;
+    padding: 0;
+    word-wrap: normal;
+    white-space: pre;
+}
+
+.d2h-code-line-ctn {
+    display: inline;
+    background: none;
+    padding: 0;
+    word-wrap: normal;
+    white-space: pre;
+}
+
+.line-num1 {
+    box-sizing: border-box;
+    float: left;
+    width: 40px;
+    overflow: hidden;
+    text-overflow: ellipsis;
+    padding-left: 3px;
+}
+
+.line-num2 {
+    box-sizing: border-box;
+    float: right;
+    width: 40px;
+    overflow: hidden;
+    text-overflow: ellipsis;
+    padding-left: 3px;
+}
+
+.d2h-code-linenumber {
+    box-sizing: border-box;
+    position: absolute;
+    width: 86px;
+    padding-left: 2px;
+    padding-right: 2px;
+    background-color: #fff;
+    color: rgba(0, 0, 0, 0.3);
+    text-align: right;
+    border: solid #eeeeee;
+    border-width: 0 1px 0 1px;
+    cursor: pointer;
+}
+
+.d2h-code-side-linenumber {
+    box-sizing: border-box;
+    position: absolute;
+    width: 56px;
+    padding-left: 5px;
+    padding-right: 5px;
+    background-color: #fff;
+    color: rgba(0, 0, 0, 0.3);
+    text-align: right;
+    border: solid #eeeeee;
+    border-width: 0 1px 0 1px;
+    cursor: pointer;
+    overflow: hidden;
+    text-overflow: ellipsis;
+}
+
+/*
+ * Changes Highlight
+ */
+
+.d2h-del {
+    background-color: #fee8e9;
+    border-color: #e9aeae;
+}
+
+.d2h-ins {
+    background-color: #dfd;
+    border-color: #b4e2b4;
+}
+
+.d2h-info {
+    background-color: #f8fafd;
+    color: rgba(0, 0, 0, 0.3);
+    border-color: #d5e4f2;
+}
+
+.d2h-file-diff .d2h-del.d2h-change {
+    background-color: #fdf2d0;
+}
+
+.d2h-file-diff .d2h-ins.d2h-change {
+    background-color: #ded;
+}
+
+.d2h-annotation-cell {
+    padding: 6px 10px;
+    background-color: #fafbfc;
+    border-top: 1px solid #e1e4e8;
+    border-bottom: 1px solid #e1e4e8;
+}
+
+.d2h-annotation-box {
+    padding: 6px 10px;
+    font-family: "Source Sans Pro", "Helvetica Neue", Helvetica, Arial, sans-serif;
+    font-size: 14px;
+    white-space: normal;
+    background-color: #fff;
+    border: 1px solid #d1d5da;
+    border-radius: 3px;
+}
+
+.d2h-annotation-header {
+    color: #586069;
+    font-size: 12px;
+}
+
+.d2h-annotation-author {
+    font-weight: bold;
+    color: #24292e;
+}
+
+.d2h-annotation-body pre {
+    padding: 6px;
+    overflow-x: auto;
+    background-color: #f6f8fa;
+}
+
+.d2h-annotation-placeholder .d2h-annotation-box {
+    visibility: hidden;
+}
+
+.d2h-cr {
+    margin-left: 4px;
+    user-select: none;
+}
+
+.d2h-no-newline {
+    color: #d73a49;
+    margin-left: 4px;
+    user-select: none;
+}
+
+.d2h-line-link {
+    color: inherit;
+    text-decoration: none;
+}
+
+.d2h-line-link:hover {
+    text-decoration: underline;
+}
+
+.d2h-syntax-keyword {
+    color: #a626a4;
+}
+
+.d2h-syntax-string {
+    color: #50a14f;
+}
+
+.d2h-syntax-comment {
+    color: #a0a1a7;
+    font-style: italic;
+}
+
+.d2h-syntax-number {
+    color: #986801;
+}
+
+.d2h-expand {
+    cursor: pointer;
+}
+
+.d2h-collapsed {
+    display: none;
+}
+
+.d2h-expand-all {
+    padding: 2px 10px;
+    font-size: 12px;
+    color: #767676;
+    border-bottom: 1px solid #ddd;
+}
+
+.d2h-expand-all ~ .d2h-file-diff .d2h-expand {
+    cursor: default;
+}
+
+.d2h-expand-all[open] ~ .d2h-file-diff .d2h-collapsed {
+    display: table-row-group;
+}
+
+.d2h-expand-all[open] ~ .d2h-file-diff .d2h-expand {
+    display: none;
+}
+
+/*
+ * File Summary List
+ */
+
+.d2h-file-list-wrapper {
+    margin-bottom: 10px;
+}
+
+.d2h-file-list-wrapper a {
+    text-decoration: none;
+    color: #3572b0;
+}
+
+.d2h-file-list-wrapper a:visited {
+    color: #3572b0;
+}
+
+.d2h-file-list-header {
+    text-align: left;
+}
+
+.d2h-file-list-title {
+    font-weight: bold;
+}
+
+.d2h-file-list-line {
+    display: -webkit-box;
+    display: -ms-flexbox;
+    display: flex;
+    text-align: left;
+}
+
+.d2h-file-list {
+    display: block;
+    list-style: none;
+    padding: 0;
+    margin: 0;
+}
+
+.d2h-file-list > li {
+    border-bottom: #ddd solid 1px;
+    padding: 5px 10px;
+    margin: 0;
+}
+
+.d2h-file-list > li:last-child {
+    border-bottom: none;
+}
+
+.d2h-lines-binary {
+    color: #767676;
+    padding: 2px;
+}
+
+.d2h-diffstat {
+    display: -webkit-box;
+    display: -ms-flexbox;
+    display: flex;
+    -webkit-box-align: center;
+    -ms-flex-align: center;
+    align-items: center;
+    margin-left: 5px;
+}
+
+.d2h-diffstat > span {
+    display: inline-block;
+    width: 8px;
+    height: 8px;
+    margin-left: 1px;
+}
+
+.d2h-diffstat-added {
+    background-color: #2cbe4e;
+}
+
+.d2h-diffstat-deleted {
+    background-color: #cb2431;
+}
+
+.d2h-diffstat-neutral {
+    background-color: #d1d5da;
+}
+
+.d2h-file-switch {
+    display: none;
+    font-size: 10px;
+    cursor: pointer;
+}
+
+.d2h-icon-wrapper {
+    line-height: 31px;
+}
+
+.d2h-icon {
+    vertical-align: middle;
+    margin-right: 10px;
+    fill: currentColor;
+}
+
+.d2h-deleted {
+    color: #c33;
+}
+
+.d2h-added {
+    color: #399839;
+}
+
+.d2h-changed {
+    color: #d0b44c;
+}
+
+.d2h-moved {
+    color: #3572b0;
+}
+
+.d2h-tag {
+    display: -webkit-box;
+    display: -ms-flexbox;
+    display: flex;
+    font-size: 10px;
+    margin-left: 5px;
+    padding: 0 2px;
+    background-color: #fff;
+}
+
+.d2h-deleted-tag {
+    border: #c33 1px solid;
+}
+
+.d2h-added-tag {
+    border: #399839 1px solid;
+}
+
+.d2h-changed-tag {
+    border: #d0b44c 1px solid;
+}
+
+.d2h-moved-tag {
+    border: #3572b0 1px solid;
+}
+
+/*
+ * Selection util.
+ */
+
+.selecting-left .d2h-code-line,
+.selecting-left .d2h-code-line *,
+.selecting-right td.d2h-code-linenumber,
+.selecting-right td.d2h-code-linenumber *,
+.selecting-left .d2h-code-side-line,
+.selecting-left .d2h-code-side-line *,
+.selecting-right td.d2h-code-side-linenumber,
+.selecting-right td.d2h-code-side-linenumber * {
+    -webkit-touch-callout: none;
+    -webkit-user-select: none;
+    -moz-user-select: none;
+    -ms-user-select: none;
+    user-select: none;
+}
+
+.selecting-left .d2h-code-line::-moz-selection,
+.selecting-left .d2h-code-line *::-moz-selection,
+.selecting-right td.d2h-code-linenumber::-moz-selection,
+.selecting-left .d2h-code-side-line::-moz-selection,
+.selecting-left .d2h-code-side-line *::-moz-selection,
+.selecting-right td.d2h-code-side-linenumber::-moz-selection,
+.selecting-right td.d2h-code-side-linenumber *::-moz-selection {
+    background: transparent;
+}
+
+.selecting-left .d2h-code-line::selection,
+.selecting-left .d2h-code-line *::selection,
+.selecting-right td.d2h-code-linenumber::selection,
+.selecting-left .d2h-code-side-line::selection,
+.selecting-left .d2h-code-side-line *::selection,
+.selecting-right td.d2h-code-side-linenumber::selection,
+.selecting-right td.d2h-code-side-linenumber *::selection {
+    background: transparent;
+}
+`
@@ -0,0 +1,18 @@
+package diff2html
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// TestCSS keeps the stylesheet linked by test/sample.html in step with CSS.
+func TestCSS(t *testing.T) {
+	sample, err := ioutil.ReadFile(filepath.Join("test", "sample.css"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(sample) != CSS {
+		t.Error("test/sample.css differs from CSS; copy CSS into it")
+	}
+}
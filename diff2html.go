@@ -1,12 +1,172 @@
 package diff2html
 
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// OutputFormat selects the html layout of a rendered diff.
+type OutputFormat string
+
+const (
+	// SideBySide renders the old and new file in two tables next to each other.
+	SideBySide OutputFormat = "side-by-side"
+	// LineByLine renders a single table with both line number columns.
+	LineByLine OutputFormat = "line-by-line"
+)
+
+// DiffStyle selects how changes inside a changed line are highlighted.
+type DiffStyle string
+
+const (
+	// DiffStyleWord highlights whole words that changed.
+	DiffStyleWord DiffStyle = "word"
+	// DiffStyleChar highlights every changed character.
+	DiffStyleChar DiffStyle = "char"
+)
+
+// RenderConfig controls how parsed files are turned into html.
+type RenderConfig struct {
+	// OutputFormat defaults to SideBySide.
+	OutputFormat OutputFormat
+	// DiffStyle defaults to DiffStyleWord.
+	DiffStyle DiffStyle
+	// IgnoreWhitespace defaults to IgnoreWhitespaceNone. Ignored
+	// whitespace is not highlighted inside changed lines.
+	IgnoreWhitespace IgnoreWhitespace
+	// WhitespaceAsContext shows a deleted and an inserted line that differ
+	// only in ignored whitespace as an unchanged line.
+	WhitespaceAsContext bool
+	// Matching defaults to MatchingNone.
+	Matching Matching
+	// MatchThreshold is the largest relative edit distance, between 0 and
+	// 1, at which matched lines are still word-highlighted. Defaults to 0.25.
+	MatchThreshold float64
+	// MatchingMaxComparisons caps deleted × inserted lines per change;
+	// larger changes are not matched. Defaults to 2500.
+	MatchingMaxComparisons int
+	// SyntaxHighlighter, when set, highlights the code of each line
+	// according to File.Language.
+	SyntaxHighlighter SyntaxHighlighter
+	// ClassNames overrides the CSS classes given to lines. Empty fields
+	// keep their DefaultClassNames value.
+	ClassNames ClassNames
+	// Source provides the file contents for ExpandContext.
+	Source SourceProvider
+	// ExpandContext is how many unchanged lines from Source are shown
+	// around each hunk, or FullContext for the whole file. Files whose
+	// source does not match the diff are shown without them.
+	ExpandContext int
+	// CollapseContext collapses runs of more than this many unchanged
+	// lines into a single row; the lines next to a change stay visible.
+	// Zero shows every line.
+	CollapseContext int
+	// CollapseStyle defaults to CollapseScript.
+	CollapseStyle CollapseStyle
+	// LinkResolver, when set, gives the links of line numbers. By default
+	// a line number links to its own anchor.
+	LinkResolver LinkResolver
+	// Annotations are review comments shown below the lines they refer to.
+	Annotations []Annotation
+	// Summary adds a list of the files with their diffstat above the diff.
+	Summary bool
+	// Document wraps the output in a complete html document with CSS
+	// inlined, instead of a bare d2h-wrapper element.
+	Document bool
+	// Title is the document title. It is only used with Document.
+	Title string
+}
+
 // GetPrettyHTML Generates the html diff.
 func GetPrettyHTML(input string) (string, error) {
-	d := newDiff(Config{})
+	files, err := Parse(input, Config{})
+	if err != nil {
+		return "", err
+	}
+	return Render(files, RenderConfig{})
+}
+
+// Parse parses a unified or git diff into files without rendering it.
+func Parse(input string, conf Config) ([]*File, error) {
+	d := newDiff(conf)
 	err := d.Parser(input)
 	if err != nil {
+		return nil, err
+	}
+	return d.Files, nil
+}
+
+// ParseReader parses a diff read from r and passes each file to fn as
+// soon as it is complete. Only the current file is kept in memory.
+// Parsing stops at the first error returned by fn.
+func ParseReader(r io.Reader, conf Config, fn func(*File) error) error {
+	d := newDiff(conf)
+	d.onFile = fn
+	return d.parse(r)
+}
+
+// Render generates the html diff for files returned by Parse.
+func Render(files []*File, conf RenderConfig) (string, error) {
+	buf := &strings.Builder{}
+	if err := RenderTo(buf, files, conf); err != nil {
 		return "", err
 	}
-	diffHTML := newSideBySide()
-	return diffHTML.GenerateSideBySideHTML(d.Files)
+	return buf.String(), nil
+}
+
+// RenderTo writes the html diff for files to w, one file at a time.
+func RenderTo(w io.Writer, files []*File, conf RenderConfig) error {
+	p, err := newPrinter(conf)
+	if err != nil {
+		return err
+	}
+	render := func(w io.Writer) error {
+		if err := p.render(w, files); err != nil {
+			return err
+		}
+		return writeCollapseScript(w, conf)
+	}
+	if conf.Document {
+		return writeDocumentHTML(w, conf.Title, render)
+	}
+	return render(w)
+}
+
+type printer interface {
+	render(w io.Writer, files []*File) error
+}
+
+func newPrinter(conf RenderConfig) (printer, error) {
+	switch conf.DiffStyle {
+	case DiffStyleWord, DiffStyleChar, "":
+	default:
+		return nil, fmt.Errorf("diff2html: unknown diff style %q", conf.DiffStyle)
+	}
+
+	switch conf.IgnoreWhitespace {
+	case IgnoreWhitespaceNone, IgnoreWhitespaceAll, IgnoreWhitespaceChange, IgnoreWhitespaceTrailing, IgnoreWhitespaceCRAtEOL, "":
+	default:
+		return nil, fmt.Errorf("diff2html: unknown whitespace mode %q", conf.IgnoreWhitespace)
+	}
+
+	switch conf.Matching {
+	case MatchingNone, MatchingLines, MatchingWords, "":
+	default:
+		return nil, fmt.Errorf("diff2html: unknown matching %q", conf.Matching)
+	}
+
+	switch conf.CollapseStyle {
+	case CollapseScript, CollapseDetails, "":
+	default:
+		return nil, fmt.Errorf("diff2html: unknown collapse style %q", conf.CollapseStyle)
+	}
+
+	switch conf.OutputFormat {
+	case LineByLine:
+		return newLineByLine(conf), nil
+	case SideBySide, "":
+		return newSideBySide(conf), nil
+	}
+	return nil, fmt.Errorf("diff2html: unknown output format %q", conf.OutputFormat)
 }
@@ -3,8 +3,11 @@ package diff2html
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"github.com/pmezard/go-difflib/difflib"
+	"html/template"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -37,13 +40,308 @@ func Test_GetPrettyHTML(t *testing.T) {
 		ToFile:   "sample.json",
 		Context:  3,
 	}
-	input, _ := difflib.GetUnifiedDiffString(diff)
-
-	fmt.Println(input)
+	input, err := difflib.GetUnifiedDiffString(diff)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	html, err := GetPrettyHTML(input)
-	fmt.Println(html)
-	fmt.Println(err)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<span class="d2h-file-name">sample.json</span>`,
+		`data-lang="json"`,
+		`&#34;a&#34;: &#34;<del>cat</del>&#34;,`,
+		`&#34;a&#34;: &#34;<ins>doc</ins>&#34;,`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("missing %q:\n%s", want, html)
+		}
+	}
+}
+
+func Test_Parse(t *testing.T) {
+	input := "diff --git a/sample.go b/sample.go\n" +
+		"index 0000001..0ddf2ba\n" +
+		"--- a/sample.go\n" +
+		"+++ b/sample.go\n" +
+		"@@ -1 +1 @@\n" +
+		"-test\n" +
+		"+test1r\n"
+
+	files, err := Parse(input, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("files: got %d, want 1", len(files))
+	}
+	file := files[0]
+	if file.OldName != "sample.go" || file.NewName != "sample.go" {
+		t.Errorf("names: got %q, %q", file.OldName, file.NewName)
+	}
+	if file.AddedLines != 1 || file.DeletedLines != 1 {
+		t.Errorf("lines: got +%d -%d", file.AddedLines, file.DeletedLines)
+	}
+	if len(file.Blocks) != 1 || len(file.Blocks[0].Lines) != 2 {
+		t.Fatalf("blocks: got %+v", file.Blocks)
+	}
+}
+
+func Test_ParseReader(t *testing.T) {
+	input := "diff --git a/a.go b/a.go\n" +
+		"--- a/a.go\n" +
+		"+++ b/a.go\n" +
+		"@@ -1 +1 @@\n" +
+		"-a\n" +
+		"+b\n" +
+		"diff --git a/b.go b/b.go\n" +
+		"deleted file mode 100644\n" +
+		"--- a/b.go\n" +
+		"+++ /dev/null\n" +
+		"@@ -1 +0,0 @@\n" +
+		"-b\n"
+
+	want, err := Parse(input, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []*File
+	err = ParseReader(strings.NewReader(input), Config{}, func(file *File) error {
+		got = append(got, file)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseReader and Parse differ: %+v, %+v", got, want)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = ParseReader(strings.NewReader(input), Config{}, func(file *File) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("got %v after %d calls, want the callback error after 1 call", err, calls)
+	}
+}
+
+func Test_Render(t *testing.T) {
+	input := "--- a/sample.js\n" +
+		"+++ b/sample.js\n" +
+		"@@ -1 +1 @@\n" +
+		"-test\n" +
+		"+test1r\n"
+
+	files, err := Parse(input, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files[0].NewName = "renamed.js"
+
+	html, err := Render(files, RenderConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html, "sample.js → renamed.js") {
+		t.Errorf("rendered html does not reflect the modified model:\n%s", html)
+	}
+}
+
+func Test_Render_outputFormat(t *testing.T) {
+	files, err := Parse("--- a/sample.js\n+++ b/sample.js\n@@ -1 +1 @@\n-test\n+test1r\n", Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	html, err := Render(files, RenderConfig{OutputFormat: LineByLine})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(html, "d2h-file-side-diff") || !strings.Contains(html, "line-num1") {
+		t.Errorf("expected line-by-line layout:\n%s", html)
+	}
+
+	if _, err := Render(files, RenderConfig{OutputFormat: "unified"}); err == nil {
+		t.Error("expected an error for an unknown output format")
+	}
+	if _, err := Render(files, RenderConfig{DiffStyle: "line"}); err == nil {
+		t.Error("expected an error for an unknown diff style")
+	}
+	if _, err := Render(files, RenderConfig{Matching: "chars"}); err == nil {
+		t.Error("expected an error for an unknown matching")
+	}
+}
+
+func Test_Render_document(t *testing.T) {
+	files, err := Parse("--- a/sample.js\n+++ b/sample.js\n@@ -1 +1 @@\n-test\n+test1r\n", Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	html, err := Render(files, RenderConfig{Document: true, Title: "a < b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(html, "<!DOCTYPE html>") || !strings.HasSuffix(html, "</html>\n") {
+		t.Errorf("expected a complete document:\n%s", html)
+	}
+	for _, want := range []string{`<meta charset="utf-8">`, "<title>a &lt; b</title>", CSS, `<div class="d2h-wrapper">`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+
+	html, err = Render(files, RenderConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(html, `<div class="d2h-wrapper">`) {
+		t.Errorf("expected only the wrapper:\n%s", html)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func Test_RenderTo(t *testing.T) {
+	files, err := Parse("--- a/sample.js\n+++ b/sample.js\n@@ -1 +1 @@\n-test\n+test1r\n", Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []OutputFormat{SideBySide, LineByLine} {
+		conf := RenderConfig{OutputFormat: format}
+		want, err := Render(files, conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := &bytes.Buffer{}
+		if err := RenderTo(buf, files, conf); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != want {
+			t.Errorf("%s: RenderTo and Render differ", format)
+		}
+		if err := RenderTo(failingWriter{}, files, conf); err == nil {
+			t.Errorf("%s: expected the write error to be returned", format)
+		}
+	}
+}
+
+func Test_Render_escapesContent(t *testing.T) {
+	payloads := []string{
+		"<script>alert(1)</script>",
+		"</ins><script>alert(1)</script><ins>",
+		"\"><img src=x onerror=alert(1)>",
+		"<del>&amp;</del>",
+	}
+
+	for _, payload := range payloads {
+		input := "--- a/sample.html\n" +
+			"+++ b/sample.html\n" +
+			"@@ -1,2 +1,3 @@\n" +
+			" " + payload + "\n" +
+			"-old " + payload + "\n" +
+			"+new " + payload + " changed\n" +
+			"+" + payload + "\n"
+		files, err := Parse(input, Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, format := range []OutputFormat{SideBySide, LineByLine} {
+			html, err := Render(files, RenderConfig{OutputFormat: format})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(html, payload) {
+				t.Errorf("%s: unescaped payload %q in:\n%s", format, payload, html)
+			}
+			for _, tag := range []string{"<script", "<img", "<ins><", "</del>&amp;"} {
+				if strings.Contains(html, tag) {
+					t.Errorf("%s: found %q for payload %q", format, tag, payload)
+				}
+			}
+			escaped := template.HTMLEscapeString(payload)
+			if n := strings.Count(html, escaped); n < 2 {
+				t.Errorf("%s: escaped payload %q found %d times, want at least 2", format, escaped, n)
+			}
+		}
+	}
+}
+
+func Test_Render_carriageReturns(t *testing.T) {
+	tests := []struct {
+		name         string
+		diff         string
+		conf         RenderConfig
+		deleted, ins int
+	}{
+		{
+			name: "unpaired insertion",
+			diff: "--- a/x\n+++ b/x\n@@ -1,2 +1,3 @@\n a\n b\n+c\r\n",
+			ins:  1,
+		},
+		{
+			name:    "unpaired deletion",
+			diff:    "--- a/x\n+++ b/x\n@@ -1,3 +1,2 @@\n a\n b\n-c\r\n",
+			deleted: 1,
+		},
+		{
+			name: "paired and unpaired",
+			diff: "--- a/x\n+++ b/x\n@@ -1,2 +1,3 @@\n-a\n-b\n+a\r\n+b\r\n+c\r\n",
+			ins:  3,
+		},
+		{
+			name: "new file",
+			diff: "--- /dev/null\n+++ b/x\n@@ -0,0 +1,2 @@\n+a\r\n+b\r\n",
+		},
+		{
+			name: "shown as context",
+			diff: "--- a/x\n+++ b/x\n@@ -1 +1 @@\n-a\r\n+a\n",
+			conf: RenderConfig{IgnoreWhitespace: IgnoreWhitespaceCRAtEOL, WhitespaceAsContext: true},
+		},
+		{
+			name: "ignored in pairs",
+			diff: "--- a/x\n+++ b/x\n@@ -1 +1 @@\n-a\r\n+b\n",
+			conf: RenderConfig{IgnoreWhitespace: IgnoreWhitespaceCRAtEOL},
+		},
+		{
+			name: "ignored without a pair",
+			diff: "--- a/x\n+++ b/x\n@@ -1,2 +1,3 @@\n a\n b\n+c\r\n",
+			conf: RenderConfig{IgnoreWhitespace: IgnoreWhitespaceCRAtEOL},
+		},
+	}
+
+	for _, tt := range tests {
+		files, err := Parse(tt.diff, Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, format := range []OutputFormat{SideBySide, LineByLine} {
+			conf := tt.conf
+			conf.OutputFormat = format
+			html, err := Render(files, conf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Count(html, crDeleted); got != tt.deleted {
+				t.Errorf("%s, %s: got %d deleted carriage returns, want %d", tt.name, format, got, tt.deleted)
+			}
+			if got := strings.Count(html, crInserted); got != tt.ins {
+				t.Errorf("%s, %s: got %d inserted carriage returns, want %d", tt.name, format, got, tt.ins)
+			}
+		}
+	}
 }
 
 func BenchmarkGetPrettyHTML(b *testing.B) {
@@ -0,0 +1,241 @@
+//go:build go1.18
+// +build go1.18
+
+package diff2html
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// addCorpus seeds f with the diffs of the golden tests.
+func addCorpus(f *testing.F, args ...interface{}) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*.diff"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, input := range inputs {
+		content, err := ioutil.ReadFile(input)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(append([]interface{}{content}, args...)...)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	addCorpus(f)
+	f.Fuzz(func(t *testing.T, input []byte) {
+		files, err := Parse(string(input), Config{})
+		if err != nil {
+			if _, ok := err.(*ParseError); !ok {
+				t.Fatalf("got %T, want a *ParseError: %v", err, err)
+			}
+			return
+		}
+		for _, file := range files {
+			for _, block := range file.Blocks {
+				for _, line := range block.Lines {
+					if line.Type != LineContext && line.Type != LineInsert && line.Type != LineDelete {
+						t.Fatalf("unknown line type %q", line.Type)
+					}
+				}
+			}
+		}
+		if err := RenderJSON(ioutil.Discard, files); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+var (
+	fuzzMatchings   = []Matching{MatchingNone, MatchingLines, MatchingWords}
+	fuzzWhitespaces = []IgnoreWhitespace{IgnoreWhitespaceNone, IgnoreWhitespaceAll, IgnoreWhitespaceChange, IgnoreWhitespaceTrailing, IgnoreWhitespaceCRAtEOL}
+)
+
+func FuzzRender(f *testing.F) {
+	addCorpus(f, true, uint8(0), false, false, uint8(0), uint8(0), false, false, int8(0))
+	addCorpus(f, false, uint8(2), true, true, uint8(1), uint8(6), true, true, int8(-1))
+	addCorpus(f, true, uint8(1), false, false, uint8(2), uint8(3), true, false, int8(2))
+	f.Fuzz(func(t *testing.T, input []byte, sideBySide bool, collapse uint8, syntax bool,
+		script bool, matching, whitespace uint8, annotate, summary bool, expand int8) {
+		files, err := Parse(string(input), Config{})
+		if err != nil {
+			return
+		}
+		conf := RenderConfig{
+			OutputFormat:        LineByLine,
+			Matching:            fuzzMatchings[int(matching)%len(fuzzMatchings)],
+			IgnoreWhitespace:    fuzzWhitespaces[int(whitespace)%len(fuzzWhitespaces)],
+			WhitespaceAsContext: int(whitespace)/len(fuzzWhitespaces)%2 == 1,
+			CollapseContext:     int(collapse % 8),
+			CollapseStyle:       CollapseDetails,
+			Summary:             summary,
+			Source:              SourceFunc(fuzzSource),
+			ExpandContext:       int(expand % 4),
+		}
+		if sideBySide {
+			conf.OutputFormat = SideBySide
+		}
+		if syntax {
+			conf.SyntaxHighlighter = BasicHighlighter{}
+		}
+		if script {
+			conf.CollapseStyle = CollapseScript
+		}
+		if expand < 0 {
+			conf.ExpandContext = FullContext
+		}
+		if annotate {
+			conf.Annotations = fuzzAnnotations(files)
+		}
+		html, err := Render(files, conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := checkMarkup(html, script); err != nil {
+			t.Fatalf("%v in:\n%s", err, html)
+		}
+	})
+}
+
+// fuzzSource rebuilds the new version of a file from its hunks, filling
+// the gaps between them with numbered lines. Files whose line numbers do
+// not add up get a source that does not match.
+func fuzzSource(file *File) ([]byte, []byte, error) {
+	lines := []string{}
+	for _, block := range file.Blocks {
+		for _, line := range block.Lines {
+			if line.Type == LineDelete || line.NewNumber < 1 || line.NewNumber > 1000 {
+				continue
+			}
+			for len(lines) < line.NewNumber {
+				lines = append(lines, strconv.Itoa(len(lines)+1)+"\n")
+			}
+			_, content := separatePrefix(file.IsCombined, line.Content)
+			if line.CRLF {
+				content += "\r"
+			}
+			lines[line.NewNumber-1] = content + "\n"
+		}
+	}
+	for i := 0; i < 3; i++ {
+		lines = append(lines, strconv.Itoa(len(lines)+1)+"\n")
+	}
+	return nil, []byte(strings.Join(lines, "")), nil
+}
+
+// fuzzAnnotations comments on every line of files, on both sides.
+func fuzzAnnotations(files []*File) []Annotation {
+	annotations := []Annotation{}
+	for _, file := range files {
+		for _, block := range file.Blocks {
+			for _, line := range block.Lines {
+				annotations = append(annotations,
+					Annotation{Path: file.OldName, Side: SideOld, Line: line.OldNumber, Author: "<b>", Body: "**a** _b_ `<c>` [d](#e)"},
+					Annotation{Path: file.NewName, Side: SideNew, Line: line.NewNumber, Body: "- <f>\n- g"},
+				)
+			}
+		}
+	}
+	return annotations
+}
+
+// renderedElements are the elements the printers write; any other element
+// in the output came from the input.
+var renderedElements = map[string]bool{
+	"a": true, "code": true, "del": true, "details": true, "div": true,
+	"em": true, "ins": true, "li": true, "ol": true, "p": true,
+	"path": true, "span": true, "strong": true, "summary": true,
+	"svg": true, "table": true, "tbody": true, "td": true, "time": true,
+	"tr": true, "ul": true,
+}
+
+// checkMarkup reports whether html is not well-formed, or has elements or
+// attributes the printers do not write. With script, the script of
+// CollapseScript is allowed.
+func checkMarkup(html string, script bool) error {
+	if script {
+		html = strings.Replace(html, collapseScript, "", -1)
+	}
+	// Control characters and invalid UTF-8 of the input are passed through
+	// as text; html allows them where xml does not.
+	html = strings.Map(func(r rune) rune {
+		if r < ' ' && r != '\t' && r != '\n' && r != '\r' || r == utf8.RuneError || r == 0xFFFE || r == 0xFFFF {
+			return ' '
+		}
+		return r
+	}, html)
+	d := xml.NewDecoder(strings.NewReader("<root>" + html + "</root>"))
+	d.Strict = true
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local == "root" {
+			continue
+		}
+		if !renderedElements[start.Name.Local] {
+			return &markupError{"element " + start.Name.Local}
+		}
+		for _, attr := range start.Attr {
+			name := strings.ToLower(attr.Name.Local)
+			if strings.HasPrefix(name, "on") || name == "style" || name == "src" {
+				return &markupError{"attribute " + name}
+			}
+			if name == "href" && !strings.HasPrefix(attr.Value, "#") {
+				return &markupError{"link " + attr.Value}
+			}
+		}
+	}
+}
+
+type markupError struct {
+	what string
+}
+
+func (e *markupError) Error() string {
+	return "unexpected " + e.what
+}
+
+func Test_checkMarkup(t *testing.T) {
+	files, err := Parse("--- a/x.html\n+++ b/x.html\n@@ -1 +1 @@\n-<script>alert(1)</script>\n+<img src=x onerror=alert(1)>\n", Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	html, err := Render(files, RenderConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkMarkup(html, false); err != nil {
+		t.Errorf("%v in:\n%s", err, html)
+	}
+	if err := checkMarkup(collapseScript, false); err == nil {
+		t.Error("the collapse script should only be allowed with script")
+	}
+	if err := checkMarkup("<div>"+collapseScript+"</div>", true); err != nil {
+		t.Errorf("the collapse script should be allowed with script: %v", err)
+	}
+
+	for _, bad := range []string{
+		`<div><script>alert(1)</script></div>`,
+		`<div onclick="x"></div>`,
+		`<a href="javascript:x">1</a>`,
+		`<div><span></div>`,
+	} {
+		if checkMarkup(bad, true) == nil {
+			t.Errorf("checkMarkup(%s) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package diff2html
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// corpusOutputs are the golden files kept next to every diff of the
+// corpus in testdata, by their suffix.
+var corpusOutputs = []struct {
+	suffix string
+	render func(w io.Writer, files []*File) error
+}{
+	{".json", func(w io.Writer, files []*File) error {
+		buf := &bytes.Buffer{}
+		if err := RenderJSON(buf, files); err != nil {
+			return err
+		}
+		// Indented, so that a change shows up as a readable diff.
+		out := &bytes.Buffer{}
+		if err := json.Indent(out, buf.Bytes(), "", "  "); err != nil {
+			return err
+		}
+		_, err := out.WriteTo(w)
+		return err
+	}},
+	{".side-by-side.html", func(w io.Writer, files []*File) error {
+		return RenderTo(w, files, RenderConfig{OutputFormat: SideBySide})
+	}},
+	{".line-by-line.html", func(w io.Writer, files []*File) error {
+		return RenderTo(w, files, RenderConfig{OutputFormat: LineByLine})
+	}},
+}
+
+// TestCorpus parses every testdata/*.diff and compares the model and the
+// html with the golden files. Run it with -update after an intended
+// change and review the diff of testdata.
+func TestCorpus(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*.diff"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no diffs in testdata")
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(input, ".diff")
+		t.Run(filepath.Base(name), func(t *testing.T) {
+			content, err := ioutil.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files, err := Parse(string(content), Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, output := range corpusOutputs {
+				buf := &bytes.Buffer{}
+				if err := output.render(buf, files); err != nil {
+					t.Fatal(err)
+				}
+				checkGolden(t, name+output.suffix, buf.String())
+			}
+		})
+	}
+}
+
+// checkGolden compares got with the golden file at path, or rewrites the
+// file when the tests run with -update.
+func checkGolden(t *testing.T, path, got string) {
+	t.Helper()
+	if *update {
+		if err := ioutil.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v; run the tests with -update to create it", err)
+	}
+	if got != string(want) {
+		t.Errorf("output differs from %s; run the tests with -update and review the diff\ngot:\n%s", path, got)
+	}
+}
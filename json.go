@@ -0,0 +1,160 @@
+package diff2html
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// JSONSchemaVersion is the version of the document written by RenderJSON.
+// It changes whenever the document changes; JSONSchema describes it.
+const JSONSchemaVersion = "1.2"
+
+// File statuses used in the JSON document.
+const (
+	statusAdded    = "added"
+	statusDeleted  = "deleted"
+	statusModified = "modified"
+	statusRenamed  = "renamed"
+	statusCopied   = "copied"
+)
+
+type jsonDiff struct {
+	Version string      `json:"version"`
+	Files   []*jsonFile `json:"files"`
+}
+
+type jsonFile struct {
+	OldName        string       `json:"oldName"`
+	NewName        string       `json:"newName"`
+	Status         string       `json:"status"`
+	Language       string       `json:"language"`
+	IsBinary       bool         `json:"isBinary"`
+	IsCombined     bool         `json:"isCombined"`
+	OldMode        string       `json:"oldMode,omitempty"`
+	NewMode        string       `json:"newMode,omitempty"`
+	ChecksumBefore string       `json:"checksumBefore,omitempty"`
+	ChecksumAfter  string       `json:"checksumAfter,omitempty"`
+	Similarity     string       `json:"similarity,omitempty"`
+	Stats          jsonStats    `json:"stats"`
+	Blocks         []*jsonBlock `json:"blocks"`
+}
+
+type jsonStats struct {
+	Added   int `json:"added"`
+	Deleted int `json:"deleted"`
+	Changed int `json:"changed"`
+	Blocks  int `json:"blocks"`
+}
+
+type jsonBlock struct {
+	Header       string      `json:"header"`
+	OldStartLine int         `json:"oldStartLine"`
+	NewStartLine int         `json:"newStartLine"`
+	Lines        []*jsonLine `json:"lines"`
+}
+
+type jsonLine struct {
+	Type      LineType `json:"type"`
+	Content   string   `json:"content"`
+	OldNumber int      `json:"oldNumber,omitempty"`
+	NewNumber int      `json:"newNumber,omitempty"`
+	NoNewline bool     `json:"noNewlineAtEOF,omitempty"`
+	CRLF      bool     `json:"crlf,omitempty"`
+}
+
+// RenderJSON writes files as a JSON document following JSONSchema.
+func RenderJSON(w io.Writer, files []*File) error {
+	doc := &jsonDiff{
+		Version: JSONSchemaVersion,
+		Files:   make([]*jsonFile, 0, len(files)),
+	}
+	for _, file := range files {
+		doc.Files = append(doc.Files, newJSONFile(file))
+	}
+	return json.NewEncoder(w).Encode(doc)
+}
+
+func newJSONFile(file *File) *jsonFile {
+	f := &jsonFile{
+		OldName:        file.OldName,
+		NewName:        file.NewName,
+		Status:         fileStatus(file),
+		Language:       file.Language,
+		IsBinary:       file.IsBinary,
+		IsCombined:     file.IsCombined,
+		OldMode:        firstNonEmpty(file.OldMode, file.DeletedFileMode, file.Mode),
+		NewMode:        firstNonEmpty(file.NewMode, file.NewFileMode, file.Mode),
+		ChecksumBefore: file.ChecksumBefore,
+		ChecksumAfter:  file.ChecksumAfter,
+		Similarity:     file.UnchangedPercentage,
+		Stats:          newJSONStats(newFileStats(file)),
+		Blocks:         make([]*jsonBlock, 0, len(file.Blocks)),
+	}
+	if file.IsNew {
+		f.OldMode = ""
+	}
+	if file.IsDeleted {
+		f.NewMode = ""
+	}
+
+	prefixSize := 1
+	if file.IsCombined {
+		prefixSize = 2
+	}
+	for _, block := range file.Blocks {
+		b := &jsonBlock{
+			Header:       block.Header,
+			OldStartLine: block.OldStartLine,
+			NewStartLine: block.NewStartLine,
+			Lines:        make([]*jsonLine, 0, len(block.Lines)),
+		}
+		for _, line := range block.Lines {
+			content := ""
+			if len(line.Content) > prefixSize {
+				content = line.Content[prefixSize:]
+			}
+			b.Lines = append(b.Lines, &jsonLine{
+				Type:      line.Type,
+				Content:   content,
+				OldNumber: line.OldNumber,
+				NewNumber: line.NewNumber,
+				NoNewline: line.NoNewlineAtEOF,
+				CRLF:      line.CRLF,
+			})
+		}
+		f.Blocks = append(f.Blocks, b)
+	}
+	return f
+}
+
+func newJSONStats(s FileStats) jsonStats {
+	return jsonStats{
+		Added:   s.Added,
+		Deleted: s.Deleted,
+		Changed: s.Changed(),
+		Blocks:  s.Blocks,
+	}
+}
+
+func fileStatus(file *File) string {
+	switch {
+	case file.IsNew, isDevNullName(file.OldName):
+		return statusAdded
+	case file.IsDeleted, isDevNullName(file.NewName):
+		return statusDeleted
+	case file.IsCopy:
+		return statusCopied
+	case file.IsRename, file.OldName != file.NewName:
+		return statusRenamed
+	}
+	return statusModified
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+package diff2html
+
+// JSONSchema is the JSON Schema of the document written by RenderJSON.
+const JSONSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "https://github.com/yu-ichiko/go-diff2html/schema/diff-1.2.json",
+  "title": "go-diff2html diff",
+  "type": "object",
+  "required": ["version", "files"],
+  "additionalProperties": false,
+  "properties": {
+    "version": {"const": "1.2"},
+    "files": {"type": "array", "items": {"$ref": "#/$defs/file"}}
+  },
+  "$defs": {
+    "file": {
+      "type": "object",
+      "required": ["oldName", "newName", "status", "language", "isBinary", "isCombined", "stats", "blocks"],
+      "additionalProperties": false,
+      "properties": {
+        "oldName": {"type": "string"},
+        "newName": {"type": "string"},
+        "status": {"enum": ["added", "deleted", "modified", "renamed", "copied"]},
+        "language": {"type": "string"},
+        "isBinary": {"type": "boolean"},
+        "isCombined": {"type": "boolean"},
+        "oldMode": {"type": "string"},
+        "newMode": {"type": "string"},
+        "checksumBefore": {"type": "string"},
+        "checksumAfter": {"type": "string"},
+        "similarity": {"type": "string", "description": "similarity index of a rename or copy, in percent"},
+        "stats": {"$ref": "#/$defs/stats"},
+        "blocks": {"type": "array", "items": {"$ref": "#/$defs/block"}}
+      }
+    },
+    "stats": {
+      "type": "object",
+      "required": ["added", "deleted", "changed", "blocks"],
+      "additionalProperties": false,
+      "properties": {
+        "added": {"type": "integer", "minimum": 0},
+        "deleted": {"type": "integer", "minimum": 0},
+        "changed": {"type": "integer", "minimum": 0},
+        "blocks": {"type": "integer", "minimum": 0}
+      }
+    },
+    "block": {
+      "type": "object",
+      "required": ["header", "oldStartLine", "newStartLine", "lines"],
+      "additionalProperties": false,
+      "properties": {
+        "header": {"type": "string"},
+        "oldStartLine": {"type": "integer", "minimum": 0},
+        "newStartLine": {"type": "integer", "minimum": 0},
+        "lines": {"type": "array", "items": {"$ref": "#/$defs/line"}}
+      }
+    },
+    "line": {
+      "type": "object",
+      "required": ["type", "content"],
+      "additionalProperties": false,
+      "properties": {
+        "type": {"enum": ["insert", "delete", "context"]},
+        "content": {"type": "string", "description": "line content without the diff prefix"},
+        "oldNumber": {"type": "integer", "minimum": 1},
+        "newNumber": {"type": "integer", "minimum": 1},
+        "noNewlineAtEOF": {"type": "boolean", "description": "the line ends its file without a newline"},
+        "crlf": {"type": "boolean", "description": "the line ends with a carriage return and a newline"}
+      }
+    }
+  }
+}
+`
@@ -0,0 +1,117 @@
+package diff2html
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRenderJSON(t *testing.T) {
+	input := "diff --git a/sample.go b/sample.go\n" +
+		"index 0000001..0ddf2ba 100644\n" +
+		"--- a/sample.go\n" +
+		"+++ b/sample.go\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		" package sample\n" +
+		"-var a = 1\n" +
+		"+var a = 2\n" +
+		"diff --git a/new.txt b/new.txt\n" +
+		"new file mode 100644\n" +
+		"--- /dev/null\n" +
+		"+++ b/new.txt\n" +
+		"@@ -0,0 +1 @@\n" +
+		"+hello\n"
+	files, err := Parse(input, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := RenderJSON(buf, files); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc jsonDiff
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Version != JSONSchemaVersion || len(doc.Files) != 2 {
+		t.Fatalf("got %s", buf.String())
+	}
+
+	file := doc.Files[0]
+	if file.Status != "modified" || file.NewMode != "100644" || file.Stats != (jsonStats{Added: 1, Deleted: 1, Changed: 2, Blocks: 1}) {
+		t.Errorf("got %+v", file)
+	}
+	want := []*jsonLine{
+		{Type: "context", Content: "package sample", OldNumber: 1, NewNumber: 1},
+		{Type: "delete", Content: "var a = 1", OldNumber: 2},
+		{Type: "insert", Content: "var a = 2", NewNumber: 2},
+	}
+	if !reflect.DeepEqual(file.Blocks[0].Lines, want) {
+		t.Errorf("lines: got %s", buf.String())
+	}
+
+	file = doc.Files[1]
+	if file.Status != "added" || file.OldMode != "" || file.NewMode != "100644" {
+		t.Errorf("got %+v", file)
+	}
+}
+
+// schemaProperties returns the property names of a schema definition.
+func schemaProperties(t *testing.T, schema map[string]interface{}, path ...string) []string {
+	node := schema
+	for _, key := range path {
+		node = node[key].(map[string]interface{})
+	}
+	names := []string{}
+	for name := range node["properties"].(map[string]interface{}) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// jsonFields returns the JSON field names of a struct.
+func jsonFields(v interface{}) []string {
+	names := []string{}
+	typ := reflect.TypeOf(v)
+	for i := 0; i < typ.NumField(); i++ {
+		names = append(names, strings.Split(typ.Field(i).Tag.Get("json"), ",")[0])
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestJSONSchema(t *testing.T) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(JSONSchema), &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	version := schema["properties"].(map[string]interface{})["version"].(map[string]interface{})["const"]
+	if version != JSONSchemaVersion {
+		t.Errorf("schema version %v, want %s", version, JSONSchemaVersion)
+	}
+
+	tests := []struct {
+		path []string
+		v    interface{}
+	}{
+		{nil, jsonDiff{}},
+		{[]string{"$defs", "file"}, jsonFile{}},
+		{[]string{"$defs", "stats"}, jsonStats{}},
+		{[]string{"$defs", "block"}, jsonBlock{}},
+		{[]string{"$defs", "line"}, jsonLine{}},
+	}
+	for _, tt := range tests {
+		got := schemaProperties(t, schema, tt.path...)
+		want := jsonFields(tt.v)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: schema has %v, document has %v", tt.path, got, want)
+		}
+	}
+}
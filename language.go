@@ -0,0 +1,231 @@
+package diff2html
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// LanguageRegistry maps file names, shebang interpreters and editor
+// modeline names to canonical language IDs such as "go" or "python".
+// Register overrides before parsing; a registry is not safe to modify
+// while it is in use.
+type LanguageRegistry struct {
+	extensions map[string]string
+	filenames  map[string]string
+	aliases    map[string]string
+}
+
+var (
+	defaultLanguages = NewLanguageRegistry()
+
+	vimModeline   = regexp.MustCompile(`\b(?:vim?|ex):.*?\b(?:ft|filetype|syntax)=([\w+-]+)`)
+	emacsModeline = regexp.MustCompile(`-\*-(?:.*?\bmode:\s*([\w+-]+).*?|\s*([\w+-]+)\s*)-\*-`)
+)
+
+// modelineLines is how many lines at the top of a file are searched for
+// a modeline. Modelines at the end of a file are not found, as the diff
+// does not tell where the file ends.
+const modelineLines = 5
+
+// NewLanguageRegistry returns a registry that knows common languages.
+func NewLanguageRegistry() *LanguageRegistry {
+	r := &LanguageRegistry{
+		extensions: map[string]string{},
+		filenames:  map[string]string{},
+		aliases:    map[string]string{},
+	}
+	for language, extensions := range map[string]string{
+		"c":          "c h",
+		"cpp":        "cc cpp cxx hh hpp hxx",
+		"csharp":     "cs",
+		"css":        "css",
+		"diff":       "diff patch",
+		"dockerfile": "dockerfile",
+		"go":         "go",
+		"html":       "htm html",
+		"java":       "java",
+		"javascript": "cjs js jsx mjs",
+		"json":       "json",
+		"kotlin":     "kt kts",
+		"lua":        "lua",
+		"makefile":   "mk mak",
+		"markdown":   "md markdown",
+		"perl":       "pl pm",
+		"php":        "php",
+		"python":     "py pyw",
+		"ruby":       "rb gemspec",
+		"rust":       "rs",
+		"scala":      "scala",
+		"shell":      "sh bash zsh",
+		"sql":        "sql",
+		"swift":      "swift",
+		"toml":       "toml",
+		"typescript": "ts tsx mts cts d.ts",
+		"xml":        "xml xsd svg",
+		"yaml":       "yml yaml",
+	} {
+		r.RegisterAlias(language, language)
+		for _, ext := range strings.Fields(extensions) {
+			r.RegisterExtension(ext, language)
+		}
+	}
+	for language, filenames := range map[string]string{
+		"dockerfile": "Dockerfile Containerfile",
+		"makefile":   "Makefile GNUmakefile makefile",
+		"ruby":       "Gemfile Rakefile",
+		"shell":      ".bashrc .bash_profile .profile .zshrc",
+	} {
+		for _, name := range strings.Fields(filenames) {
+			r.RegisterFilename(name, language)
+		}
+	}
+	for language, aliases := range map[string]string{
+		"cpp":        "c++",
+		"javascript": "js node nodejs",
+		"makefile":   "make",
+		"python":     "py",
+		"ruby":       "rb",
+		"shell":      "sh bash zsh ksh dash",
+		"typescript": "ts deno",
+	} {
+		for _, alias := range strings.Fields(aliases) {
+			r.RegisterAlias(alias, language)
+		}
+	}
+	return r
+}
+
+// RegisterExtension maps files ending in "."+ext to language. ext may
+// contain dots, like "d.ts"; the longest registered extension wins.
+func (r *LanguageRegistry) RegisterExtension(ext, language string) {
+	r.extensions[strings.ToLower(strings.TrimPrefix(ext, "."))] = language
+}
+
+// RegisterFilename maps files with the base name name to language.
+func (r *LanguageRegistry) RegisterFilename(name, language string) {
+	r.filenames[name] = language
+}
+
+// RegisterAlias maps a shebang interpreter or modeline name to language.
+func (r *LanguageRegistry) RegisterAlias(name, language string) {
+	r.aliases[strings.ToLower(name)] = language
+}
+
+// Detect returns the canonical language of file, or "" if it is unknown.
+// A modeline wins over the file name, which wins over a shebang line,
+// which wins over the extension.
+func (r *LanguageRegistry) Detect(file *File) string {
+	name := file.NewName
+	if name == "" || isDevNullName(name) {
+		name = file.OldName
+	}
+	head := fileHead(file)
+
+	if language := r.modeline(head); language != "" {
+		return language
+	}
+	if language, ok := r.filenames[path.Base(name)]; ok {
+		return language
+	}
+	if len(head) > 0 {
+		if language := r.shebang(head[0]); language != "" {
+			return language
+		}
+	}
+	return r.extension(path.Base(name))
+}
+
+func (r *LanguageRegistry) extension(base string) string {
+	base = strings.ToLower(strings.TrimLeft(base, "."))
+	for i := strings.Index(base, "."); i >= 0; {
+		if language, ok := r.extensions[base[i+1:]]; ok {
+			return language
+		}
+		next := strings.Index(base[i+1:], ".")
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return ""
+}
+
+func (r *LanguageRegistry) shebang(line string) string {
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+	fields := strings.Fields(line[2:])
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				interpreter = path.Base(field)
+				break
+			}
+		}
+	}
+	return r.alias(interpreter)
+}
+
+func (r *LanguageRegistry) modeline(lines []string) string {
+	for _, line := range lines {
+		if m := vimModeline.FindStringSubmatch(line); m != nil {
+			return r.alias(m[1])
+		}
+		if m := emacsModeline.FindStringSubmatch(line); m != nil {
+			return r.alias(m[1] + m[2])
+		}
+	}
+	return ""
+}
+
+// alias looks up name, then name without a version suffix, so that
+// "python3.11" is found as "python".
+func (r *LanguageRegistry) alias(name string) string {
+	name = strings.ToLower(name)
+	if language, ok := r.aliases[name]; ok {
+		return language
+	}
+	return r.aliases[strings.TrimRight(name, "0123456789.")]
+}
+
+// fileHead returns the first lines of the file as far as the diff shows
+// them, preferring the new version.
+func fileHead(file *File) []string {
+	if len(file.Blocks) == 0 {
+		return nil
+	}
+	if lines := blockHead(file.Blocks[0], file.IsCombined, true); len(lines) > 0 {
+		return lines
+	}
+	return blockHead(file.Blocks[0], file.IsCombined, false)
+}
+
+// blockHead returns the lines of block that start one side of the file.
+func blockHead(block *Block, isCombined, newSide bool) []string {
+	prefixSize := 1
+	if isCombined {
+		prefixSize = 2
+	}
+	lines := []string{}
+	for _, line := range block.Lines {
+		number := line.OldNumber
+		if newSide {
+			number = line.NewNumber
+		}
+		if number != len(lines)+1 || number > modelineLines {
+			continue
+		}
+		content := ""
+		if len(line.Content) > prefixSize {
+			content = line.Content[prefixSize:]
+		}
+		lines = append(lines, content)
+	}
+	return lines
+}
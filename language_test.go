@@ -0,0 +1,70 @@
+package diff2html
+
+import (
+	"testing"
+)
+
+func TestLanguageRegistry_Detect(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"extension", "--- a/main.go\n+++ b/main.go\n@@ -10 +10 @@\n-a\n+b\n", "go"},
+		{"compound extension", "--- a/types.d.ts\n+++ b/types.d.ts\n@@ -10 +10 @@\n-a\n+b\n", "typescript"},
+		{"unknown extension", "--- a/archive.tar.gz\n+++ b/archive.tar.gz\n@@ -10 +10 @@\n-a\n+b\n", ""},
+		{"filename", "--- a/build/Makefile\n+++ b/build/Makefile\n@@ -10 +10 @@\n-a\n+b\n", "makefile"},
+		{"dotfile", "--- a/.bashrc\n+++ b/.bashrc\n@@ -10 +10 @@\n-a\n+b\n", "shell"},
+		{"shebang", "--- /dev/null\n+++ b/deploy\n@@ -0,0 +1,2 @@\n+#!/usr/bin/env python3\n+print(1)\n", "python"},
+		{"shebang of deleted file", "--- a/run\n+++ /dev/null\n@@ -1,2 +0,0 @@\n-#!/bin/bash\n-echo\n", "shell"},
+		{"shebang below the first line", "--- a/run\n+++ b/run\n@@ -2 +2 @@\n-#!/bin/bash\n+#!/bin/sh\n", ""},
+		{"vim modeline", "--- a/conf.txt\n+++ b/conf.txt\n@@ -1,2 +1,2 @@\n # vim: set ft=ruby:\n-a\n+b\n", "ruby"},
+		{"emacs modeline", "--- a/x.h\n+++ b/x.h\n@@ -1,2 +1,2 @@\n // -*- mode: c++ -*-\n-a\n+b\n", "cpp"},
+		{"rename", "diff --git a/a.py b/b.rb\nsimilarity index 100%\nrename from a.py\nrename to b.rb\n", "ruby"},
+	}
+	for _, tt := range tests {
+		files, err := Parse(tt.input, Config{})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(files) != 1 {
+			t.Fatalf("%s: got %d files", tt.name, len(files))
+		}
+		if files[0].Language != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, files[0].Language, tt.want)
+		}
+	}
+}
+
+func TestLanguageRegistry_register(t *testing.T) {
+	languages := NewLanguageRegistry()
+	languages.RegisterExtension(".tmpl", "html")
+	languages.RegisterFilename("BUILD", "starlark")
+	languages.RegisterAlias("mypython", "python")
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"--- a/page.tmpl\n+++ b/page.tmpl\n@@ -10 +10 @@\n-a\n+b\n", "html"},
+		{"--- a/BUILD\n+++ b/BUILD\n@@ -10 +10 @@\n-a\n+b\n", "starlark"},
+		{"--- a/run\n+++ b/run\n@@ -1 +1 @@\n-#!/opt/mypython\n+#!/opt/mypython -u\n", "python"},
+	}
+	for _, tt := range tests {
+		files, err := Parse(tt.input, Config{Languages: languages})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if files[0].Language != tt.want {
+			t.Errorf("got %q, want %q", files[0].Language, tt.want)
+		}
+	}
+
+	files, err := Parse(tests[0].input, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files[0].Language != "" {
+		t.Errorf("overrides leaked into the default registry: %q", files[0].Language)
+	}
+}
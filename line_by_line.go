@@ -0,0 +1,297 @@
+package diff2html
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"math"
+)
+
+var (
+	lineByLineFileDiffOpenTemplate = template.Must(template.New("line-by-line-file-diff-open").Parse(lineByLineFileDiffOpen))
+	lineByLineNumbersTemplate      = template.Must(template.New("line-by-line-numbers").Parse(lineByLineNumbers))
+)
+
+func newLineByLine(conf RenderConfig) *lineByLinePrinter {
+	return &lineByLinePrinter{conf: conf, classes: conf.ClassNames.withDefaults()}
+}
+
+type lineByLinePrinter struct {
+	conf    RenderConfig
+	classes ClassNames
+	links   lineLinker      // of the file being written
+	notes   fileAnnotations // of the file being written
+	endings lineEndings     // of the file being written
+}
+
+func (p *lineByLinePrinter) GenerateLineByLineHTML(files []*File) (string, error) {
+	buf := &bytes.Buffer{}
+	if err := p.render(buf, files); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (p *lineByLinePrinter) render(w io.Writer, files []*File) error {
+	return writeWrapperHTML(w, files, p.conf, p.writeDiffHTML)
+}
+
+// writeDiffHTML writes the html of one file. The rows go straight to w,
+// between the opening and closing parts of the file template.
+func (p *lineByLinePrinter) writeDiffHTML(w io.Writer, file *File, id string) error {
+	p.links = lineLinker{file: file, id: id, resolve: p.conf.LinkResolver}
+	p.notes = annotationsOf(file, p.conf.Annotations)
+	p.endings = lineEndingsOf(file, p.conf.IgnoreWhitespace)
+
+	pathHTML, err := makePathHTML(file)
+	if err != nil {
+		return err
+	}
+	expandAllHTML, err := makeExpandAllHTML(file, p.conf)
+	if err != nil {
+		return err
+	}
+
+	err = lineByLineFileDiffOpenTemplate.Execute(w, struct {
+		FileHTMLID string
+		FilePath   template.HTML
+		ExpandAll  template.HTML
+		Language   string
+	}{
+		FileHTMLID: id,
+		FilePath:   template.HTML(pathHTML),
+		ExpandAll:  template.HTML(expandAllHTML),
+		Language:   file.Language,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(file.Blocks) > 0 {
+		if err := p.genLineByLineFileHTML(w, file); err != nil {
+			return err
+		}
+	} else {
+		if err := p.genEmptyDiff(w); err != nil {
+			return err
+		}
+	}
+
+	_, err = io.WriteString(w, lineByLineFileDiffClose)
+	return err
+}
+
+func (p *lineByLinePrinter) makeColumnLineNumberHTML(w io.Writer, blockHeader string) error {
+	return genericColumnLineNumberTemplate.Execute(w, struct {
+		BlockHeader  string
+		Type         string
+		LineClass    string
+		ContentClass string
+	}{
+		BlockHeader:  blockHeader,
+		Type:         p.classes.Info,
+		LineClass:    "d2h-code-linenumber",
+		ContentClass: "d2h-code-line",
+	})
+}
+
+func (p *lineByLinePrinter) makeCollapseHTML(w io.Writer, id, hidden int) error {
+	return writeCollapseOpen(w, p.conf, id, hidden, p.classes.Info, "d2h-code-linenumber", "d2h-code-line")
+}
+
+func (p *lineByLinePrinter) genLineByLineFileHTML(w io.Writer, file *File) error {
+	collapseID := 0
+	for _, block := range file.Blocks {
+		if err := p.makeColumnLineNumberHTML(w, block.Header); err != nil {
+			return err
+		}
+
+		oldLines := make([]*Line, 0)
+		newLines := make([]*Line, 0)
+
+		matcher := newLineMatcher(p.conf, file.IsCombined)
+		processChangeBlock := func() error {
+			for _, group := range matcher.match(oldLines, newLines) {
+				if !group.highlight {
+					if err := p.processLines(w, file, group.oldLines, group.newLines); err != nil {
+						return err
+					}
+					continue
+				}
+
+				oldLen := len(group.oldLines)
+				newLen := len(group.newLines)
+				common := int(math.Min(float64(oldLen), float64(newLen)))
+
+				// Paired lines are shown as all deletions followed by all insertions.
+				processedNewLines := &bytes.Buffer{}
+				for i := 0; i < common; i++ {
+					oldLine := group.oldLines[i]
+					newLine := group.newLines[i]
+					if showsAsContext(p.conf, file, oldLine, newLine) {
+						// Keep the lines in order around the unchanged one.
+						if _, err := processedNewLines.WriteTo(w); err != nil {
+							return err
+						}
+						// The row shows the new line. The whitespace mode
+						// ignores carriage returns, so none is marked.
+						prefix, unprefixed := separatePrefix(file.IsCombined, newLine.Content)
+						content := lineHTML(contentHTML(unprefixed, p.conf.SyntaxHighlighter, file.Language), newLine, false)
+						if err := p.genSingleLineHTML(w, file.IsCombined, p.classes.line(LineContext, false), oldLine.OldNumber, newLine.NewNumber, content, contextPrefix(prefix)); err != nil {
+							return err
+						}
+						continue
+					}
+					highlight := diffHighlight(oldLine.Content, newLine.Content, file.IsCombined, p.conf.DiffStyle, p.conf.IgnoreWhitespace, p.conf.SyntaxHighlighter, file.Language)
+					oldHTML, newHTML := p.endings.pairHTML(highlight, oldLine, newLine)
+					if err := p.genSingleLineHTML(w, file.IsCombined, p.classes.line(LineDelete, true), oldLine.OldNumber, oldLine.NewNumber, oldHTML, highlight.First.Prefix); err != nil {
+						return err
+					}
+					if err := p.genSingleLineHTML(processedNewLines, file.IsCombined, p.classes.line(LineInsert, true), newLine.OldNumber, newLine.NewNumber, newHTML, highlight.Second.Prefix); err != nil {
+						return err
+					}
+				}
+				if _, err := processedNewLines.WriteTo(w); err != nil {
+					return err
+				}
+
+				if err := p.processLines(w, file, group.oldLines[common:], group.newLines[common:]); err != nil {
+					return err
+				}
+			}
+
+			oldLines = make([]*Line, 0)
+			newLines = make([]*Line, 0)
+			return nil
+		}
+
+		runs := collapsedRuns(block.Lines, p.conf.CollapseContext)
+		collapseEnd := -1
+		for i, line := range block.Lines {
+			prefix, content := separatePrefix(file.IsCombined, line.Content)
+			escapedLine := lineHTML(contentHTML(content, p.conf.SyntaxHighlighter, file.Language), line, p.endings.crChanged(line))
+
+			if line.Type != LineInsert && (len(newLines) > 0 || (line.Type != LineDelete && len(oldLines) > 0)) {
+				if err := processChangeBlock(); err != nil {
+					return err
+				}
+			}
+
+			if end, ok := runs[i]; ok {
+				collapseID++
+				collapseEnd = end
+				if err := p.makeCollapseHTML(w, collapseID, end-i); err != nil {
+					return err
+				}
+			}
+
+			if line.Type == LineContext {
+				if err := p.genSingleLineHTML(w, file.IsCombined, p.classes.line(line.Type, false), line.OldNumber, line.NewNumber, escapedLine, prefix); err != nil {
+					return err
+				}
+				if i+1 == collapseEnd {
+					if err := writeCollapseClose(w); err != nil {
+						return err
+					}
+				}
+			} else if line.Type == LineInsert && len(oldLines) == 0 {
+				if err := p.genSingleLineHTML(w, file.IsCombined, p.classes.line(line.Type, false), line.OldNumber, line.NewNumber, escapedLine, prefix); err != nil {
+					return err
+				}
+			} else if line.Type == LineDelete {
+				oldLines = append(oldLines, line)
+			} else if line.Type == LineInsert && len(oldLines) > 0 {
+				newLines = append(newLines, line)
+			} else {
+				if err := processChangeBlock(); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := processChangeBlock(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *lineByLinePrinter) processLines(w io.Writer, file *File, oldLines, newLines []*Line) error {
+	for _, line := range append(append([]*Line{}, oldLines...), newLines...) {
+		prefix, content := separatePrefix(file.IsCombined, line.Content)
+		escapedLine := lineHTML(contentHTML(content, p.conf.SyntaxHighlighter, file.Language), line, p.endings.crChanged(line))
+		if err := p.genSingleLineHTML(w, file.IsCombined, p.classes.line(line.Type, false), line.OldNumber, line.NewNumber, escapedLine, prefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *lineByLinePrinter) genSingleLineHTML(w io.Writer, isCombined bool, lineClass string, oldNumber, newNumber int, content template.HTML, prefix string) error {
+	lineNumber, err := p.makeLineNumbersHTML(oldNumber, newNumber)
+	if err != nil {
+		return err
+	}
+
+	err = genericLineTemplate.Execute(w, struct {
+		Type         string
+		Prefix       string
+		Content      template.HTML
+		LineNumber   template.HTML
+		LineClass    string
+		ContentClass string
+	}{
+		Type:         lineClass,
+		Prefix:       prefix,
+		Content:      content,
+		LineNumber:   template.HTML(lineNumber),
+		LineClass:    "d2h-code-linenumber",
+		ContentClass: "d2h-code-line",
+	})
+	if err != nil {
+		return err
+	}
+
+	// The comments on a line follow it.
+	for _, a := range p.notes.at(oldNumber, newNumber) {
+		if err := writeAnnotationHTML(w, a, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *lineByLinePrinter) makeLineNumbersHTML(oldNumber, newNumber int) (string, error) {
+	oldNumberHTML, err := p.links.html(SideOld, oldNumber)
+	if err != nil {
+		return "", err
+	}
+	newNumberHTML, err := p.links.html(SideNew, newNumber)
+	if err != nil {
+		return "", err
+	}
+
+	buf := &bytes.Buffer{}
+	err = lineByLineNumbersTemplate.Execute(buf, struct {
+		OldNumber template.HTML
+		NewNumber template.HTML
+	}{
+		OldNumber: oldNumberHTML,
+		NewNumber: newNumberHTML,
+	})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (p *lineByLinePrinter) genEmptyDiff(w io.Writer) error {
+	return genericEmptyDiffTemplate.Execute(w, struct {
+		Type         string
+		ContentClass string
+	}{
+		Type:         p.classes.Info,
+		ContentClass: "d2h-code-line",
+	})
+}
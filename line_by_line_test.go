@@ -0,0 +1,56 @@
+package diff2html
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNewLineByLine(t *testing.T) {
+	input := "--- a/sample.js\n" +
+		"+++ b/sample.js\n" +
+		"@@ -1,2 +1,3 @@\n" +
+		" context\n" +
+		"-test\n" +
+		"+test1r\n" +
+		"+test2r\n" +
+		"@@ -10 +11 @@\n" +
+		"-last\n" +
+		"+first\n"
+	d := newDiff(Config{})
+	if err := d.Parser(input); err != nil {
+		t.Fatal(err)
+	}
+
+	html, err := newLineByLine(RenderConfig{}).GenerateLineByLineHTML(d.Files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(html, `<table class="d2h-diff-table">`); n != 1 {
+		t.Errorf("tables: got %d, want 1", n)
+	}
+	for _, want := range []string{
+		"@@ -1,2 &#43;1,3 @@",
+		"@@ -10 &#43;11 @@",
+		`<div class="line-num1"><a id="d2h-sample-js-L1" class="d2h-line-link" href="#d2h-sample-js-L1">1</a></div>
+<div class="line-num2"><a id="d2h-sample-js-R1" class="d2h-line-link" href="#d2h-sample-js-R1">1</a></div>`,
+		`<div class="line-num1"></div>
+<div class="line-num2"><a id="d2h-sample-js-R3" class="d2h-line-link" href="#d2h-sample-js-R3">3</a></div>`,
+		`<div class="line-num1"><a id="d2h-sample-js-L10" class="d2h-line-link" href="#d2h-sample-js-L10">10</a></div>
+<div class="line-num2"></div>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("missing %q in:\n%s", want, html)
+		}
+	}
+}
+
+func TestLineByLinePrinter_genEmptyDiff(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := newLineByLine(RenderConfig{}).genEmptyDiff(buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "File without changes") {
+		t.Errorf("got %s", buf.String())
+	}
+}
@@ -0,0 +1,121 @@
+package diff2html
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// maxLookahead is how many lines after the current one the parser may
+// look at. It covers the extended header lines git writes between a
+// rename or copy line and the first hunk header.
+const maxLookahead = 8
+
+var crlf = regexp.MustCompile(`\r\n?`)
+
+// lineReader reads the input one line at a time and keeps a small window
+// of the following lines, so the parser never holds the whole input.
+type lineReader struct {
+	r         *bufio.Reader
+	normalize bool
+	lines     []readLine // lines[0] is the current line, the rest is lookahead
+	pending   []readLine // lines of the last physical line not yet in lines
+	prev      string
+	number    int
+	started   bool
+	eof       bool
+	err       error
+}
+
+// readLine is a line without its line ending.
+type readLine struct {
+	text string
+	crlf bool // the line ended with "\r\n"
+}
+
+// newLineReader returns a reader splitting lines at "\n". With normalize,
+// lone carriage returns split lines as well, and no line is CRLF.
+func newLineReader(r io.Reader, normalize bool) *lineReader {
+	return &lineReader{r: bufio.NewReader(r), normalize: normalize}
+}
+
+// next advances to the next line and reports whether there is one.
+func (lr *lineReader) next() bool {
+	if lr.started && len(lr.lines) > 0 {
+		lr.prev = lr.lines[0].text
+		lr.lines = lr.lines[1:]
+		lr.number++
+	}
+	if !lr.started {
+		lr.started = true
+		lr.number = 1
+	}
+	lr.fill(1)
+	return len(lr.lines) > 0
+}
+
+func (lr *lineReader) line() string {
+	return lr.lines[0].text
+}
+
+// crlf reports whether the current line ended with "\r\n".
+func (lr *lineReader) crlf() bool {
+	return lr.lines[0].crlf
+}
+
+// peek returns the line i lines after the current one, or "" past the end.
+func (lr *lineReader) peek(i int) string {
+	if i > maxLookahead {
+		return ""
+	}
+	lr.fill(i + 1)
+	if i < len(lr.lines) {
+		return lr.lines[i].text
+	}
+	return ""
+}
+
+func (lr *lineReader) fill(n int) {
+	for len(lr.lines) < n {
+		if len(lr.pending) > 0 {
+			lr.lines = append(lr.lines, lr.pending[0])
+			lr.pending = lr.pending[1:]
+			continue
+		}
+		if lr.eof {
+			return
+		}
+		lr.pending = lr.readLines()
+	}
+}
+
+// readLines reads one physical line. With normalize, it is split at lone
+// carriage returns.
+func (lr *lineReader) readLines() []readLine {
+	raw, err := lr.r.ReadString('\n')
+	if err != nil {
+		lr.eof = true
+		if err != io.EOF {
+			lr.err = err
+			return nil
+		}
+		if raw == "" {
+			return nil
+		}
+	}
+	if !lr.normalize {
+		if strings.HasSuffix(raw, "\r\n") {
+			return []readLine{{text: raw[:len(raw)-2], crlf: true}}
+		}
+		return []readLine{{text: strings.TrimSuffix(raw, "\n")}}
+	}
+
+	raw = strings.TrimSuffix(raw, "\n")
+	raw = strings.TrimSuffix(raw, "\r")
+	lines := []readLine{}
+	for _, text := range strings.Split(crlf.ReplaceAllString(raw, "\n"), "\n") {
+		lines = append(lines, readLine{text: text})
+	}
+	return lines
+}
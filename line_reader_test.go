@@ -0,0 +1,93 @@
+package diff2html
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readAll returns the lines of input, with "\r\n" appended to CRLF lines.
+func readAll(input string, normalize bool) []string {
+	lr := newLineReader(strings.NewReader(input), normalize)
+	lines := []string{}
+	for lr.next() {
+		line := lr.line()
+		if lr.crlf() {
+			line += "\r\n"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestLineReader(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"", []string{}},
+		{"a\nb\n", []string{"a", "b"}},
+		{"a\nb", []string{"a", "b"}},
+		{"a\r\nb\r\n", []string{"a\r\n", "b\r\n"}},
+		{"a\rb\n", []string{"a\rb"}},
+		{"a\r\r\nb\r", []string{"a\r\r\n", "b\r"}},
+		{"-a\n\\ No newline at end of file\n+b\n", []string{"-a", "\\ No newline at end of file", "+b"}},
+		{"a\n\n\nb\n", []string{"a", "", "", "b"}},
+	}
+
+	for _, tt := range tests {
+		if got := readAll(tt.input, false); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLineReader_normalize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"", []string{}},
+		{"a\nb\n", []string{"a", "b"}},
+		{"a\nb", []string{"a", "b"}},
+		{"a\r\nb\r\n", []string{"a", "b"}},
+		{"a\rb\n", []string{"a", "b"}},
+		{"a\r\r\nb", []string{"a", "", "b"}},
+		{"a\n\n\nb\n", []string{"a", "", "", "b"}},
+	}
+
+	for _, tt := range tests {
+		if got := readAll(tt.input, true); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLineReader_peek(t *testing.T) {
+	input := ""
+	for i := 0; i < 20; i++ {
+		input += strings.Repeat("x", i) + "\n"
+	}
+	lr := newLineReader(strings.NewReader(input), false)
+
+	if !lr.next() {
+		t.Fatal("expected a line")
+	}
+	if got := lr.peek(2); got != "xx" {
+		t.Errorf("peek(2): got %q", got)
+	}
+	if got := lr.peek(maxLookahead + 1); got != "" {
+		t.Errorf("peek past the window: got %q", got)
+	}
+	if len(lr.lines) > maxLookahead+1 {
+		t.Errorf("window holds %d lines", len(lr.lines))
+	}
+
+	lr.next()
+	if lr.number != 2 || lr.prev != "" || lr.line() != "x" {
+		t.Errorf("got line %d %q after %q", lr.number, lr.line(), lr.prev)
+	}
+	if got := lr.peek(19); got != "" {
+		t.Errorf("peek past the end: got %q", got)
+	}
+}
@@ -0,0 +1,76 @@
+package diff2html
+
+import (
+	"bytes"
+	"html/template"
+	"strconv"
+)
+
+// Side is the version of a file a line number refers to.
+type Side string
+
+const (
+	// SideOld numbers lines of the file before the change.
+	SideOld Side = "old"
+	// SideNew numbers lines of the file after the change.
+	SideNew Side = "new"
+)
+
+// LinkResolver returns the URL a line number links to, for example the
+// line in a code browser at File.ChecksumBefore or File.ChecksumAfter.
+// Returning "" links the line number to its own anchor.
+type LinkResolver func(file *File, side Side, line int) string
+
+var (
+	lineLinkTemplate = template.Must(template.New("line-link").Parse(lineLink))
+)
+
+// lineLinker makes the anchors of the line numbers of one file, such as
+// "d2h-src-main-go-L17" for old line 17 and "-R42" for new line 42. Its
+// zero value renders bare numbers.
+type lineLinker struct {
+	file    *File
+	id      string
+	resolve LinkResolver
+}
+
+func (l lineLinker) html(side Side, number int) (template.HTML, error) {
+	if number <= 0 {
+		return "", nil
+	}
+	if l.file == nil {
+		return template.HTML(strconv.Itoa(number)), nil
+	}
+
+	id := lineAnchor(l.id, side, number)
+	href := ""
+	if l.resolve != nil {
+		href = l.resolve(l.file, side, number)
+	}
+	if href == "" {
+		href = "#" + id
+	}
+
+	buf := &bytes.Buffer{}
+	err := lineLinkTemplate.Execute(buf, struct {
+		ID     string
+		Href   string
+		Number int
+	}{
+		ID:     id,
+		Href:   href,
+		Number: number,
+	})
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// lineAnchor returns the anchor of a line of the file with anchor fileID.
+func lineAnchor(fileID string, side Side, number int) string {
+	if side == SideOld {
+		return fileID + "-L" + strconv.Itoa(number)
+	}
+	return fileID + "-R" + strconv.Itoa(number)
+}
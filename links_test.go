@@ -0,0 +1,54 @@
+package diff2html
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func Test_lineAnchor(t *testing.T) {
+	if got := lineAnchor("d2h-main-go", SideOld, 17); got != "d2h-main-go-L17" {
+		t.Errorf("got %s", got)
+	}
+	if got := lineAnchor("d2h-main-go", SideNew, 42); got != "d2h-main-go-R42" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestRender_linkResolver(t *testing.T) {
+	input := "diff --git a/main.go b/main.go\n" +
+		"index 1a2b3c4..5d6e7f8 100644\n" +
+		"--- a/main.go\n" +
+		"+++ b/main.go\n" +
+		"@@ -17 +42 @@\n" +
+		"-a\n" +
+		"+b\n"
+	files, err := Parse(input, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolve := func(file *File, side Side, line int) string {
+		if side == SideOld {
+			return fmt.Sprintf("https://code.example.com/%s/%s#L%d", file.ChecksumBefore, file.OldName, line)
+		}
+		if line == 42 {
+			return "javascript:alert(1)"
+		}
+		return ""
+	}
+
+	for _, format := range []OutputFormat{SideBySide, LineByLine} {
+		html, err := Render(files, RenderConfig{OutputFormat: format, LinkResolver: resolve})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			`<a id="d2h-main-go-L17" class="d2h-line-link" href="https://code.example.com/1a2b3c4/main.go#L17">17</a>`,
+			`<a id="d2h-main-go-R42" class="d2h-line-link" href="#ZgotmplZ">42</a>`,
+		} {
+			if !strings.Contains(html, want) {
+				t.Errorf("%s: missing %s", format, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package diff2html
+
+import (
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+var (
+	markdownCode   = regexp.MustCompile("`([^`]+)`")
+	markdownBold   = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	markdownItalic = regexp.MustCompile(`(^|[^\w*])[*_]([^*_]+)[*_]`)
+	markdownLink   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	markdownList   = regexp.MustCompile(`^\s*[-*+]\s+`)
+)
+
+// renderMarkdown renders the small part of markdown that review comments
+// use: paragraphs, bullet lists, fenced code blocks, inline code, bold,
+// italic and links. Everything else is escaped, and links only keep
+// http, https and mailto URLs or paths within the site.
+func renderMarkdown(text string) template.HTML {
+	buf := &strings.Builder{}
+	paragraph := []string{}
+	list := []string{}
+	code := []string{}
+	inCode := false
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			buf.WriteString("<p>" + strings.Join(paragraph, "<br>\n") + "</p>\n")
+			paragraph = paragraph[:0]
+		}
+		if len(list) > 0 {
+			buf.WriteString("<ul>\n")
+			for _, item := range list {
+				buf.WriteString("<li>" + item + "</li>\n")
+			}
+			buf.WriteString("</ul>\n")
+			list = list[:0]
+		}
+	}
+
+	for _, line := range strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			if inCode {
+				buf.WriteString("<pre><code>" + template.HTMLEscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+				code = code[:0]
+			} else {
+				flush()
+			}
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			code = append(code, line)
+			continue
+		}
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+		case markdownList.MatchString(line):
+			if len(paragraph) > 0 {
+				flush()
+			}
+			list = append(list, renderInlineMarkdown(markdownList.ReplaceAllString(line, "")))
+		default:
+			if len(list) > 0 {
+				flush()
+			}
+			paragraph = append(paragraph, renderInlineMarkdown(strings.TrimSpace(line)))
+		}
+	}
+	if inCode {
+		buf.WriteString("<pre><code>" + template.HTMLEscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+	}
+	flush()
+
+	return template.HTML(strings.TrimSuffix(buf.String(), "\n"))
+}
+
+// renderInlineMarkdown renders the inline markup of one line. Code spans
+// are cut out first so that their content is not formatted.
+func renderInlineMarkdown(line string) string {
+	parts := markdownCode.Split(line, -1)
+	spans := markdownCode.FindAllStringSubmatch(line, -1)
+
+	buf := &strings.Builder{}
+	for i, part := range parts {
+		buf.WriteString(renderEmphasis(part))
+		if i < len(spans) {
+			buf.WriteString("<code>" + template.HTMLEscapeString(spans[i][1]) + "</code>")
+		}
+	}
+	return buf.String()
+}
+
+func renderEmphasis(text string) string {
+	links := markdownLink.FindAllStringSubmatchIndex(text, -1)
+	buf := &strings.Builder{}
+	last := 0
+	for _, m := range links {
+		buf.WriteString(renderStyle(text[last:m[0]]))
+		label := renderStyle(text[m[2]:m[3]])
+		if url := text[m[4]:m[5]]; safeURL(url) {
+			buf.WriteString(`<a href="` + template.HTMLEscapeString(url) + `" rel="nofollow">` + label + "</a>")
+		} else {
+			buf.WriteString(label)
+		}
+		last = m[1]
+	}
+	buf.WriteString(renderStyle(text[last:]))
+	return buf.String()
+}
+
+func renderStyle(text string) string {
+	text = template.HTMLEscapeString(text)
+	text = markdownBold.ReplaceAllString(text, "<strong>$1</strong>")
+	return markdownItalic.ReplaceAllString(text, "$1<em>$2</em>")
+}
+
+func safeURL(url string) bool {
+	lower := strings.ToLower(url)
+	for _, prefix := range []string{"http://", "https://", "mailto:"} {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return (strings.HasPrefix(url, "/") && !strings.HasPrefix(url, "//")) || strings.HasPrefix(url, "#")
+}
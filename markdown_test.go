@@ -0,0 +1,29 @@
+package diff2html
+
+import "testing"
+
+func Test_renderMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"paragraphs", "one\ntwo\n\nthree", "<p>one<br>\ntwo</p>\n<p>three</p>"},
+		{"list", "see:\n- a\n* b", "<p>see:</p>\n<ul>\n<li>a</li>\n<li>b</li>\n</ul>"},
+		{"code block", "```go\nif a < b {\n```", "<pre><code>if a &lt; b {</code></pre>"},
+		{"inline", "**bold**, *it* and `a **b**`", "<p><strong>bold</strong>, <em>it</em> and <code>a **b**</code></p>"},
+		{"html", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>"},
+		{"link", "[docs](https://example.com/a?b=1&c=2)", `<p><a href="https://example.com/a?b=1&amp;c=2" rel="nofollow">docs</a></p>`},
+		{"relative link", "[line](#d2h-main-go-R3)", `<p><a href="#d2h-main-go-R3" rel="nofollow">line</a></p>`},
+		{"unsafe link", "[x](javascript:alert(1))", "<p>x)</p>"},
+		{"protocol relative link", "[x](//evil.example.com)", "<p>x</p>"},
+		{"quoted link", `[x](https://example.com/"onmouseover="a)`, `<p><a href="https://example.com/&#34;onmouseover=&#34;a" rel="nofollow">x</a></p>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(renderMarkdown(tt.text)); got != tt.want {
+				t.Errorf("renderMarkdown(%q) =\n%s\nwant\n%s", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package diff2html
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Matching selects how the deleted and inserted lines of a change are
+// paired up for word-level highlighting.
+type Matching string
+
+const (
+	// MatchingNone pairs the i-th deleted line with the i-th inserted line.
+	MatchingNone Matching = "none"
+	// MatchingLines pairs lines by the edit distance of their characters.
+	MatchingLines Matching = "lines"
+	// MatchingWords pairs lines by the edit distance of their words.
+	MatchingWords Matching = "words"
+)
+
+const (
+	defaultMatchThreshold         = 0.25
+	defaultMatchingMaxComparisons = 2500
+
+	// maxLineSizeInBlockForComparison keeps the edit distance cheap; blocks
+	// with longer lines fall back to MatchingNone.
+	maxLineSizeInBlockForComparison = 200
+)
+
+var wordRegexp = regexp.MustCompile(`[\p{L}\p{N}_]+|\s+|[^\p{L}\p{N}_\s]`)
+
+// changeGroup is a run of deleted lines shown against a run of inserted
+// lines. Lines at the same index form a pair; highlight tells whether the
+// pairs correspond closely enough to be word-highlighted.
+type changeGroup struct {
+	oldLines  []*Line
+	newLines  []*Line
+	highlight bool
+}
+
+type lineMatcher struct {
+	matching       Matching
+	threshold      float64
+	maxComparisons int
+	whitespace     IgnoreWhitespace
+	isCombined     bool
+}
+
+func newLineMatcher(conf RenderConfig, isCombined bool) *lineMatcher {
+	m := &lineMatcher{
+		matching:       conf.Matching,
+		threshold:      conf.MatchThreshold,
+		maxComparisons: conf.MatchingMaxComparisons,
+		whitespace:     conf.IgnoreWhitespace,
+		isCombined:     isCombined,
+	}
+	if m.threshold == 0 {
+		m.threshold = defaultMatchThreshold
+	}
+	if m.maxComparisons == 0 {
+		m.maxComparisons = defaultMatchingMaxComparisons
+	}
+	return m
+}
+
+// match splits a change into groups of corresponding lines.
+func (m *lineMatcher) match(oldLines, newLines []*Line) []changeGroup {
+	if !m.enabled(oldLines, newLines) {
+		return []changeGroup{{oldLines: oldLines, newLines: newLines, highlight: true}}
+	}
+
+	dist := make([][]float64, len(oldLines))
+	for i, oldLine := range oldLines {
+		dist[i] = make([]float64, len(newLines))
+		for j, newLine := range newLines {
+			dist[i][j] = m.distance(oldLine, newLine)
+		}
+	}
+
+	groups := []changeGroup{}
+	// group follows rematch from diff2html: pair the closest lines, then
+	// recurse into the lines before and after them.
+	var group func(a0, a1, b0, b1 int)
+	group = func(a0, a1, b0, b1 int) {
+		if a0 == a1 || b0 == b1 || (a1-a0)+(b1-b0) < 3 {
+			g := changeGroup{oldLines: oldLines[a0:a1], newLines: newLines[b0:b1]}
+			if a1-a0 == 1 && b1-b0 == 1 {
+				g.highlight = dist[a0][b0] <= m.threshold
+			}
+			groups = append(groups, g)
+			return
+		}
+
+		bestI, bestJ := a0, b0
+		best := math.Inf(1)
+		for i := a0; i < a1; i++ {
+			for j := b0; j < b1; j++ {
+				if dist[i][j] < best {
+					best = dist[i][j]
+					bestI, bestJ = i, j
+				}
+			}
+		}
+
+		if bestI > a0 || bestJ > b0 {
+			group(a0, bestI, b0, bestJ)
+		}
+		group(bestI, bestI+1, bestJ, bestJ+1)
+		if a1 > bestI+1 || b1 > bestJ+1 {
+			group(bestI+1, a1, bestJ+1, b1)
+		}
+	}
+	group(0, len(oldLines), 0, len(newLines))
+
+	return groups
+}
+
+func (m *lineMatcher) enabled(oldLines, newLines []*Line) bool {
+	if m.matching != MatchingLines && m.matching != MatchingWords {
+		return false
+	}
+	if len(oldLines) == 0 || len(newLines) == 0 || len(oldLines)*len(newLines) > m.maxComparisons {
+		return false
+	}
+	for _, lines := range [][]*Line{oldLines, newLines} {
+		for _, line := range lines {
+			if len(line.Content) > maxLineSizeInBlockForComparison {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// distance is the edit distance of two lines divided by the sum of their
+// lengths in tokens, 0 for identical lines. Lines with nothing in common
+// score the longer length over the sum: 0.5 at equal lengths, and 1 only
+// when one line is empty.
+func (m *lineMatcher) distance(oldLine, newLine *Line) float64 {
+	a := m.tokens(oldLine)
+	b := m.tokens(newLine)
+	if len(a)+len(b) == 0 {
+		return 0
+	}
+	return float64(levenshtein(a, b)) / float64(len(a)+len(b))
+}
+
+func (m *lineMatcher) tokens(line *Line) []string {
+	prefixSize := 1
+	if m.isCombined {
+		prefixSize = 2
+	}
+	content := ""
+	if len(line.Content) > prefixSize {
+		content = line.Content[prefixSize:]
+	}
+	if m.whitespace.ignores() {
+		content, _ = normalizeWhitespace(content, m.whitespace)
+	}
+	if m.matching == MatchingWords {
+		return wordRegexp.FindAllString(content, -1)
+	}
+	return strings.Split(content, "")
+}
+
+func levenshtein(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,106 @@
+package diff2html
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_levenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(strings.Split(tt.a, ""), strings.Split(tt.b, "")); got != tt.want {
+			t.Errorf("%q, %q: got %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func makeLines(prefix string, contents ...string) []*Line {
+	lines := []*Line{}
+	for _, content := range contents {
+		lines = append(lines, &Line{Content: prefix + content})
+	}
+	return lines
+}
+
+func groupContents(groups []changeGroup) [][2][]string {
+	result := [][2][]string{}
+	for _, g := range groups {
+		var pair [2][]string
+		for _, l := range g.oldLines {
+			pair[0] = append(pair[0], l.Content)
+		}
+		for _, l := range g.newLines {
+			pair[1] = append(pair[1], l.Content)
+		}
+		result = append(result, pair)
+	}
+	return result
+}
+
+func TestLineMatcher_match(t *testing.T) {
+	oldLines := makeLines("-", "func sum(a, b int) int {", "\treturn a + b")
+	newLines := makeLines("+", "// sum adds two numbers.", "func sum(a, b, c int) int {", "\treturn a + b + c")
+
+	for _, matching := range []Matching{MatchingLines, MatchingWords} {
+		groups := newLineMatcher(RenderConfig{Matching: matching}, false).match(oldLines, newLines)
+		got := groupContents(groups)
+		if len(got) != 3 {
+			t.Fatalf("%s: got %q", matching, got)
+		}
+		if len(got[0][0]) != 0 || got[0][1][0] != "+// sum adds two numbers." {
+			t.Errorf("%s: the new comment should stand alone, got %q", matching, got[0])
+		}
+		if got[1][0][0] != "-func sum(a, b int) int {" || got[1][1][0] != "+func sum(a, b, c int) int {" || !groups[1].highlight {
+			t.Errorf("%s: got %q", matching, got[1])
+		}
+		if got[2][0][0] != "-\treturn a + b" || got[2][1][0] != "+\treturn a + b + c" || !groups[2].highlight {
+			t.Errorf("%s: got %q", matching, got[2])
+		}
+	}
+}
+
+func TestLineMatcher_match_threshold(t *testing.T) {
+	oldLines := makeLines("-", "completely different")
+	newLines := makeLines("+", "nothing alike here")
+
+	groups := newLineMatcher(RenderConfig{Matching: MatchingLines}, false).match(oldLines, newLines)
+	if len(groups) != 1 || groups[0].highlight {
+		t.Errorf("unrelated lines should not be highlighted: %+v", groups)
+	}
+
+	groups = newLineMatcher(RenderConfig{Matching: MatchingLines, MatchThreshold: 1}, false).match(oldLines, newLines)
+	if len(groups) != 1 || !groups[0].highlight {
+		t.Errorf("threshold 1 should highlight every pair: %+v", groups)
+	}
+}
+
+func TestLineMatcher_match_disabled(t *testing.T) {
+	oldLines := makeLines("-", "a", "b")
+	newLines := makeLines("+", "x", "a", "b")
+
+	tests := []RenderConfig{
+		{},
+		{Matching: MatchingNone},
+		{Matching: MatchingLines, MatchingMaxComparisons: 5},
+	}
+	for _, conf := range tests {
+		groups := newLineMatcher(conf, false).match(oldLines, newLines)
+		if len(groups) != 1 || len(groups[0].oldLines) != 2 || len(groups[0].newLines) != 3 || !groups[0].highlight {
+			t.Errorf("%+v: expected positional pairing, got %q", conf, groupContents(groups))
+		}
+	}
+
+	long := makeLines("-", strings.Repeat("a", maxLineSizeInBlockForComparison+1), "b")
+	groups := newLineMatcher(RenderConfig{Matching: MatchingLines}, false).match(long, newLines)
+	if len(groups) != 1 {
+		t.Errorf("long lines should not be matched, got %d groups", len(groups))
+	}
+}
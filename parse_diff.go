@@ -1,6 +1,8 @@
 package diff2html
 
 import (
+	"fmt"
+	"io"
 	"regexp"
 	"strconv"
 	"strings"
@@ -10,13 +12,21 @@ const (
 	oldFileNameHeader = "--- "
 	newFileNameHeader = "+++ "
 	hunkHeaderPrefix  = "@@"
+	// noNewlinePrefix starts the "\ No newline at end of file" line. diff
+	// translates the rest of it.
+	noNewlinePrefix = "\\ "
+)
+
+// LineType tells what a line of a hunk does to the file.
+type LineType string
 
-	inserts       = "d2h-ins"
-	deletes       = "d2h-del"
-	insertChanges = "d2h-ins d2h-change"
-	deleteChanges = "d2h-del d2h-change"
-	context       = "d2h-cntx"
-	info          = "d2h-info"
+const (
+	// LineContext is a line both versions have in common.
+	LineContext LineType = "context"
+	// LineInsert is a line only the new version has.
+	LineInsert LineType = "insert"
+	// LineDelete is a line only the old version has.
+	LineDelete LineType = "delete"
 )
 
 var (
@@ -24,10 +34,10 @@ var (
 	newMode             = regexp.MustCompile(`^new mode (\d{6})`)
 	deletedFileMode     = regexp.MustCompile(`^deleted file mode (\d{6})`)
 	newFileMode         = regexp.MustCompile(`^new file mode (\d{6})`)
-	copyFrom            = regexp.MustCompile(`^copy from "?(.+)"?`)
-	copyTo              = regexp.MustCompile(`^copy to "?(.+)"?`)
-	renameFrom          = regexp.MustCompile(`^rename from "?(.+)"?`)
-	renameTo            = regexp.MustCompile(`^rename to "?(.+)"?`)
+	copyFrom            = regexp.MustCompile(`^copy from (.+)`)
+	copyTo              = regexp.MustCompile(`^copy to (.+)`)
+	renameFrom          = regexp.MustCompile(`^rename from (.+)`)
+	renameTo            = regexp.MustCompile(`^rename to (.+)`)
 	similarityIndex     = regexp.MustCompile(`^similarity index (\d+)%`)
 	dissimilarityIndex  = regexp.MustCompile(`^dissimilarity index (\d+)%`)
 	index               = regexp.MustCompile(`^index ([0-9a-z]+)\.\.([0-9a-z]+)\s*(\d{6})?`)
@@ -37,16 +47,21 @@ var (
 	combinedMode        = regexp.MustCompile(`^mode (\d{6}),(\d{6})\.\.(\d{6})`)
 	combinedNewFile     = regexp.MustCompile(`^new file mode (\d{6})`)
 	combinedDeletedFile = regexp.MustCompile(`^deleted file mode (\d{6}),(\d{6})`)
-	gitDiffStart        = regexp.MustCompile(`^diff --git "?(.+)"? "?(.+)"?`)
-	filenameRegexp      = regexp.MustCompile(`\s+\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)? \+\d{4}.*$`)
-	crlf                = regexp.MustCompile(`\r\n?`)
-	combined1           = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+(\d+)(?:,\d+)? @@.*`)
-	combined2           = regexp.MustCompile(`^@@@ -(\d+)(?:,\d+)? -(\d+)(?:,\d+)? \+(\d+)(?:,\d+)? @@@.*`)
+	gitDiffStart        = regexp.MustCompile(`^diff --git ("(?:[^"\\]|\\.)*"|.+) ("(?:[^"\\]|\\.)*"|.+)$`)
+	filenameRegexp      = regexp.MustCompile(`\s+\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)? [+-]\d{4}.*$`)
+	combined1           = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@.*`)
+	combined2           = regexp.MustCompile(`^@@@ -(\d+)(?:,(\d+))? -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@@.*`)
 )
 
+// Config controls how a diff is parsed.
 type Config struct {
 	DstPrefix string
 	SrcPrefix string
+	// Languages detects File.Language. Defaults to NewLanguageRegistry().
+	Languages *LanguageRegistry
+	// NormalizeLineEndings treats lone carriage returns as line breaks and
+	// drops the carriage return of CRLF lines, so Line.CRLF is never set.
+	NormalizeLineEndings bool
 }
 
 func newDiff(conf Config) *Diff {
@@ -60,6 +75,9 @@ type Diff struct {
 	conf  Config
 	Files []*File
 
+	// onFile, when set, receives each completed file instead of Files.
+	onFile func(*File) error
+
 	currentFile     *File
 	currentBlock    *Block
 	oldLine         int
@@ -67,6 +85,30 @@ type Diff struct {
 	newLine         int
 	possibleOldName string
 	possibleNewName string
+
+	lineNumber      int
+	blockLineNumber int
+	countLines      bool
+	oldRemaining    int
+	oldRemaining2   int
+	newRemaining    int
+	// lastLine is the hunk line parsed last, from input line lastLineNumber.
+	lastLine       *Line
+	lastLineNumber int
+	// transportCR is set when the whole diff was converted to CRLF, so
+	// each line of the current hunk has one carriage return too many.
+	transportCR bool
+}
+
+// ParseError describes input the parser could not make sense of.
+type ParseError struct {
+	Line    int    // 1-based line number in the input
+	Content string // the offending line
+	Reason  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("diff2html: line %d: %s: %q", e.Line, e.Reason, e.Content)
 }
 
 type File struct {
@@ -103,63 +145,86 @@ type Block struct {
 }
 
 type Line struct {
-	Content   string `json:"content"`
-	Type      string `json:"type"`
-	OldNumber int    `json:"oldNumber"`
-	NewNumber int    `json:"newNumber"`
+	Content   string   `json:"content"`
+	Type      LineType `json:"type"`
+	OldNumber int      `json:"oldNumber"`
+	NewNumber int      `json:"newNumber"`
+	// NoNewlineAtEOF is set on the last line of a file that does not end
+	// with a newline.
+	NoNewlineAtEOF bool `json:"noNewlineAtEOF"`
+	// CRLF is set on a line that ends with "\r\n". Content keeps lone
+	// carriage returns.
+	CRLF bool `json:"crlf"`
 }
 
 func (b *Block) addLine(l *Line) {
 	b.Lines = append(b.Lines, l)
 }
 
-func (d *Diff) startFile() {
-	d.saveBlock()
-	d.saveFile()
+func (d *Diff) startFile() error {
+	if err := d.saveBlock(); err != nil {
+		return err
+	}
+	if err := d.saveFile(); err != nil {
+		return err
+	}
 
 	d.currentFile = &File{
 		DeletedLines: 0,
 		AddedLines:   0,
 		Blocks:       []*Block{},
 	}
+	return nil
 }
 
-func (d *Diff) startBlock(line string) {
-	d.saveBlock()
+func (d *Diff) startBlock(line string) error {
+	if err := d.saveBlock(); err != nil {
+		return err
+	}
 
-	if values := combined1.FindStringSubmatch(line); len(values) >= 3 {
+	d.countLines = false
+	if values := combined1.FindStringSubmatch(line); len(values) >= 5 {
 		d.currentFile.IsCombined = false
-		oldLine, err := strconv.Atoi(values[1])
-		if err != nil {
-			panic(err) // todo...
+		var err error
+		if d.oldLine, err = d.atoi(line, values[1]); err != nil {
+			return err
+		}
+		if d.newLine, err = d.atoi(line, values[3]); err != nil {
+			return err
 		}
-		d.oldLine = oldLine
-		newLine, err := strconv.Atoi(values[2])
-		if err != nil {
-			panic(err) // todo...
+		if d.oldRemaining, err = d.hunkCount(line, values[2]); err != nil {
+			return err
 		}
-		d.newLine = newLine
-	} else if values = combined2.FindStringSubmatch(line); len(values) >= 4 {
+		if d.newRemaining, err = d.hunkCount(line, values[4]); err != nil {
+			return err
+		}
+		d.oldRemaining2 = 0
+		d.countLines = true
+	} else if values = combined2.FindStringSubmatch(line); len(values) >= 7 {
 		d.currentFile.IsCombined = true
-		oldLine, err := strconv.Atoi(values[1])
-		if err != nil {
-			panic(err)
+		var err error
+		if d.oldLine, err = d.atoi(line, values[1]); err != nil {
+			return err
+		}
+		if d.oldLine2, err = d.atoi(line, values[3]); err != nil {
+			return err
+		}
+		if d.newLine, err = d.atoi(line, values[5]); err != nil {
+			return err
 		}
-		d.oldLine = oldLine
-		oldLine2, err := strconv.Atoi(values[2])
-		if err != nil {
-			panic(err)
+		if d.oldRemaining, err = d.hunkCount(line, values[2]); err != nil {
+			return err
 		}
-		d.oldLine2 = oldLine2
-		newLine, err := strconv.Atoi(values[3])
-		if err != nil {
-			panic(err)
+		if d.oldRemaining2, err = d.hunkCount(line, values[4]); err != nil {
+			return err
 		}
-		d.newLine = newLine
+		if d.newRemaining, err = d.hunkCount(line, values[6]); err != nil {
+			return err
+		}
+		d.countLines = true
+	} else if strings.HasPrefix(line, hunkHeaderPrefix) {
+		return d.parseError(line, "malformed hunk header")
 	} else {
-		//if strings.HasPrefix(line, hunkHeaderPrefix) {
-		//
-		//}
 		d.oldLine = 0
 		d.newLine = 0
 		d.currentFile.IsCombined = false
@@ -172,11 +237,35 @@ func (d *Diff) startBlock(line string) {
 		NewStartLine:  d.newLine,
 		Header:        line,
 	}
+	d.blockLineNumber = d.lineNumber
+	return nil
+}
+
+func (d *Diff) atoi(line, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, d.parseError(line, "invalid number in hunk header")
+	}
+	return n, nil
+}
+
+// hunkCount parses the line count of a hunk header range, which defaults
+// to 1 when the header leaves it out.
+func (d *Diff) hunkCount(line, value string) (int, error) {
+	if value == "" {
+		return 1, nil
+	}
+	return d.atoi(line, value)
+}
+
+func (d *Diff) parseError(line, reason string) *ParseError {
+	return &ParseError{Line: d.lineNumber, Content: line, Reason: reason}
 }
 
-func (d *Diff) createLine(line string) {
+func (d *Diff) createLine(line string, crlf bool) error {
 	currentLine := &Line{}
 	currentLine.Content = line
+	currentLine.CRLF = crlf
 
 	newLinePrefixes := []string{"+", " +"}
 	delLinePrefixes := []string{"-", " -"}
@@ -185,38 +274,99 @@ func (d *Diff) createLine(line string) {
 		delLinePrefixes = []string{"-"}
 	}
 
+	if err := d.countLine(line); err != nil {
+		return err
+	}
+
 	if startsWith(line, newLinePrefixes) {
 		d.currentFile.AddedLines++
-		currentLine.Type = inserts
+		currentLine.Type = LineInsert
 		currentLine.OldNumber = 0
 		currentLine.NewNumber = d.newLine
 		d.newLine++
 		d.currentBlock.addLine(currentLine)
 	} else if startsWith(line, delLinePrefixes) {
 		d.currentFile.DeletedLines++
-		currentLine.Type = deletes
+		currentLine.Type = LineDelete
 		currentLine.OldNumber = d.oldLine
 		d.oldLine++
 		currentLine.NewNumber = 0
 		d.currentBlock.addLine(currentLine)
 	} else {
-		currentLine.Type = context
+		currentLine.Type = LineContext
 		currentLine.OldNumber = d.oldLine
 		d.oldLine++
 		currentLine.NewNumber = d.newLine
 		d.newLine++
 		d.currentBlock.addLine(currentLine)
 	}
+	d.lastLine = currentLine
+	d.lastLineNumber = d.lineNumber
+
+	// Like git apply, end the hunk once it has all the lines its header
+	// declares: what follows, such as the message of the next commit in
+	// git log -p, is not part of it.
+	if d.countLines && d.oldRemaining == 0 && d.oldRemaining2 == 0 && d.newRemaining == 0 {
+		return d.saveBlock()
+	}
+	return nil
+}
+
+// countLine checks a hunk line against the line counts of its header.
+// There is one column per parent: a deleted line belongs to the parents
+// whose column is "-", any other line to the result and to the parents
+// whose column does not add it.
+func (d *Diff) countLine(line string) error {
+	if !d.countLines {
+		return nil
+	}
+	columns := []byte{' '}
+	if d.currentFile.IsCombined {
+		columns = []byte{' ', ' '}
+	}
+	copy(columns, line) // an empty context line may have lost its spaces
+	deleted := strings.Contains(string(columns), "-")
+
+	old := [2]int{}
+	for i := range columns {
+		if (deleted && columns[i] == '-') || (!deleted && columns[i] != '+') {
+			old[i] = 1
+		}
+	}
+	newLines := 1
+	if deleted {
+		newLines = 0
+	}
+	if d.oldRemaining < old[0] || d.oldRemaining2 < old[1] || d.newRemaining < newLines {
+		return d.parseError(line, "hunk has more lines than its header declares")
+	}
+	d.oldRemaining -= old[0]
+	d.oldRemaining2 -= old[1]
+	d.newRemaining -= newLines
+	return nil
+}
+
+// hunkIncomplete reports whether the current hunk still expects lines.
+func (d *Diff) hunkIncomplete() bool {
+	return d.currentBlock != nil && d.countLines && (d.oldRemaining > 0 || d.oldRemaining2 > 0 || d.newRemaining > 0)
 }
 
-func (d *Diff) saveBlock() {
+func (d *Diff) saveBlock() error {
 	if d.currentBlock != nil {
+		if d.hunkIncomplete() {
+			return &ParseError{
+				Line:    d.blockLineNumber,
+				Content: d.currentBlock.Header,
+				Reason:  "truncated hunk",
+			}
+		}
 		d.currentFile.Blocks = append(d.currentFile.Blocks, d.currentBlock)
 		d.currentBlock = nil
 	}
+	return nil
 }
 
-func (d *Diff) saveFile() {
+func (d *Diff) saveFile() error {
 	if d.currentFile != nil {
 		if d.currentFile.OldName == "" {
 			d.currentFile.OldName = d.possibleOldName
@@ -225,40 +375,59 @@ func (d *Diff) saveFile() {
 			d.currentFile.NewName = d.possibleNewName
 		}
 		if d.currentFile.NewName != "" {
-			d.Files = append(d.Files, d.currentFile)
+			languages := d.conf.Languages
+			if languages == nil {
+				languages = defaultLanguages
+			}
+			d.currentFile.Language = languages.Detect(d.currentFile)
+			if d.onFile != nil {
+				if err := d.onFile(d.currentFile); err != nil {
+					return err
+				}
+			} else {
+				d.Files = append(d.Files, d.currentFile)
+			}
 			d.currentFile = nil
 		}
 	}
 
 	d.possibleOldName = ""
 	d.possibleNewName = ""
+	return nil
 }
 
+// Parser parses input and appends the files it contains to d.Files.
 func (d *Diff) Parser(input string) error {
-	input = strings.Replace(input, "\\ No newline at end of file", "", -1)
-	input = crlf.ReplaceAllString(input, "\n")
-	lines := strings.Split(input, "\n")
+	return d.parse(strings.NewReader(input))
+}
 
-	for idx, line := range lines {
-		if line == "" || strings.HasPrefix(line, "*") {
-			continue
+func (d *Diff) parse(r io.Reader) error {
+	lr := newLineReader(r, d.conf.NormalizeLineEndings)
+	for lr.next() {
+		line, crlf := lr.line(), lr.crlf()
+		d.lineNumber = lr.number
+		if d.transportCR && crlf {
+			// Only a second carriage return belongs to the line.
+			crlf = strings.HasSuffix(line, "\r")
+			line = strings.TrimSuffix(line, "\r")
 		}
 
-		prevLine := ""
-		if idx > 0 {
-			prevLine = lines[idx-1]
+		// Some tools strip the trailing space of empty context lines.
+		if line == "" && d.hunkIncomplete() {
+			line = " "
 		}
-		nxtLine := ""
-		if idx+1 < len(lines) {
-			nxtLine = lines[idx+1]
-		}
-		afterNxtLine := ""
-		if idx+2 < len(lines) {
-			afterNxtLine = lines[idx+2]
+		if line == "" || strings.HasPrefix(line, "*") {
+			continue
 		}
 
+		prevLine := lr.prev
+		nxtLine := lr.peek(1)
+		afterNxtLine := lr.peek(2)
+
 		if strings.HasPrefix(line, "diff") {
-			d.startFile()
+			if err := d.startFile(); err != nil {
+				return err
+			}
 			if values := gitDiffStart.FindStringSubmatch(line); len(values) >= 3 {
 				var err error
 				d.possibleOldName, err = getFilename("", values[1], d.conf.DstPrefix)
@@ -276,7 +445,9 @@ func (d *Diff) Parser(input string) error {
 
 		if d.currentFile == nil || (!d.currentFile.IsGiftDiff &&
 			(strings.HasPrefix(line, oldFileNameHeader) && strings.HasPrefix(nxtLine, newFileNameHeader) && strings.HasPrefix(afterNxtLine, hunkHeaderPrefix))) {
-			d.startFile()
+			if err := d.startFile(); err != nil {
+				return err
+			}
 		}
 
 		if (strings.HasPrefix(line, oldFileNameHeader) && strings.HasPrefix(nxtLine, newFileNameHeader)) ||
@@ -291,7 +462,6 @@ func (d *Diff) Parser(input string) error {
 			}
 			if d.currentFile != nil && d.currentFile.OldName == "" && strings.HasPrefix(line, "--- ") && srcFilename != "" {
 				d.currentFile.OldName = srcFilename
-				d.currentFile.Language = getExtension(d.currentFile.OldName, d.currentFile.Language)
 				continue
 			}
 
@@ -305,14 +475,28 @@ func (d *Diff) Parser(input string) error {
 			}
 			if d.currentFile != nil && d.currentFile.NewName == "" && strings.HasPrefix(line, "+++ ") && dstFilename != "" {
 				d.currentFile.NewName = dstFilename
-				d.currentFile.Language = getExtension(d.currentFile.NewName, d.currentFile.Language)
 				continue
 			}
 		}
 
 		if (d.currentFile != nil && strings.HasPrefix(line, hunkHeaderPrefix)) ||
-			(d.currentFile != nil && d.currentFile.IsGiftDiff && d.currentFile.OldName != "" && d.currentFile.NewName != "" && d.currentBlock == nil) {
-			d.startBlock(line)
+			(d.currentFile != nil && d.currentFile.IsGiftDiff && d.currentFile.OldName != "" && d.currentFile.NewName != "" && d.currentBlock == nil && len(d.currentFile.Blocks) == 0) {
+			if err := d.startBlock(line); err != nil {
+				return err
+			}
+			d.transportCR = lr.crlf()
+			continue
+		}
+
+		// The no newline marker follows the line it refers to, which may
+		// have completed its hunk.
+		if strings.HasPrefix(line, noNewlinePrefix) && d.lastLine != nil && d.lastLineNumber == d.lineNumber-1 {
+			d.lastLine.NoNewlineAtEOF = true
+			// The carriage return did not end the line.
+			if d.lastLine.CRLF {
+				d.lastLine.Content += "\r"
+				d.lastLine.CRLF = false
+			}
 			continue
 		}
 
@@ -324,11 +508,13 @@ func (d *Diff) Parser(input string) error {
 		 */
 		if d.currentBlock != nil &&
 			(strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") || strings.HasPrefix(line, " ")) {
-			d.createLine(line)
+			if err := d.createLine(line, crlf); err != nil {
+				return err
+			}
 			continue
 		}
 
-		doesNotExistHunkHeader := existHunkHeader(lines, line, idx)
+		doesNotExistHunkHeader := !existHunkHeader(lr)
 
 		if values := oldMode.FindStringSubmatch(line); len(values) >= 2 {
 			d.currentFile.OldMode = values[1]
@@ -342,22 +528,22 @@ func (d *Diff) Parser(input string) error {
 			d.currentFile.IsNew = true
 		} else if values = copyFrom.FindStringSubmatch(line); len(values) >= 2 {
 			if doesNotExistHunkHeader {
-				d.currentFile.OldName = values[1]
+				d.currentFile.OldName = unquoteFilename(values[1])
 			}
 			d.currentFile.IsCopy = true
 		} else if values = copyTo.FindStringSubmatch(line); len(values) >= 2 {
 			if doesNotExistHunkHeader {
-				d.currentFile.NewName = values[1]
+				d.currentFile.NewName = unquoteFilename(values[1])
 			}
 			d.currentFile.IsCopy = true
 		} else if values = renameFrom.FindStringSubmatch(line); len(values) >= 2 {
 			if doesNotExistHunkHeader {
-				d.currentFile.OldName = values[1]
+				d.currentFile.OldName = unquoteFilename(values[1])
 			}
 			d.currentFile.IsRename = true
 		} else if values = renameTo.FindStringSubmatch(line); len(values) >= 2 {
 			if doesNotExistHunkHeader {
-				d.currentFile.NewName = values[1]
+				d.currentFile.NewName = unquoteFilename(values[1])
 			}
 			d.currentFile.IsRename = true
 		} else if values = binaryFiles.FindStringSubmatch(line); len(values) >= 3 {
@@ -372,10 +558,14 @@ func (d *Diff) Parser(input string) error {
 				return err
 			}
 			d.currentFile.NewName = newName
-			d.startBlock("Binary file")
+			if err := d.startBlock("Binary file"); err != nil {
+				return err
+			}
 		} else if values = binaryDiff.FindStringSubmatch(line); len(values) >= 1 {
 			d.currentFile.IsBinary = true
-			d.startBlock(line)
+			if err := d.startBlock(line); err != nil {
+				return err
+			}
 		} else if values = similarityIndex.FindStringSubmatch(line); len(values) >= 2 {
 			d.currentFile.UnchangedPercentage = values[1]
 		} else if values = dissimilarityIndex.FindStringSubmatch(line); len(values) >= 2 {
@@ -401,18 +591,14 @@ func (d *Diff) Parser(input string) error {
 		}
 	}
 
-	d.saveBlock()
-	d.saveFile()
-
-	return nil
-}
+	if lr.err != nil {
+		return lr.err
+	}
 
-func getExtension(filename, language string) string {
-	names := strings.Split(filename, ".")
-	if len(names) > 1 {
-		return names[len(names)-1]
+	if err := d.saveBlock(); err != nil {
+		return err
 	}
-	return language
+	return d.saveFile()
 }
 
 func getSrcFilename(line string, conf Config) (string, error) {
@@ -432,9 +618,9 @@ func getFilename(linePrefix, line, extraPrefix string) (string, error) {
 	var reg *regexp.Regexp
 	var err error
 	if linePrefix != "" {
-		reg, err = regexp.Compile("^" + linePrefix + `?(.+?)"?$`)
+		reg, err = regexp.Compile("^" + linePrefix + " (.+)$")
 	} else {
-		reg, err = regexp.Compile(`^"?(.+?)"?$`)
+		reg, err = regexp.Compile(`^(.+)$`)
 	}
 	if err != nil {
 		return "", err
@@ -443,7 +629,7 @@ func getFilename(linePrefix, line, extraPrefix string) (string, error) {
 	var filename string
 	values := reg.FindStringSubmatch(line)
 	if len(values) >= 2 {
-		filename = values[1]
+		filename = unquoteFilename(filenameRegexp.ReplaceAllString(values[1], ""))
 		matchingPrefixes := []string{}
 		for _, p := range prefixes {
 			if strings.HasPrefix(filename, p) {
@@ -454,34 +640,45 @@ func getFilename(linePrefix, line, extraPrefix string) (string, error) {
 		if len(matchingPrefixes) >= 1 {
 			filename = filename[len(matchingPrefixes[0]):]
 		}
-
-		filename = filenameRegexp.ReplaceAllString(filename, "")
 	}
 
 	return filename, nil
 }
 
+// unquoteFilename decodes a name git quoted because it has special
+// characters, such as "caf\303\251.txt" for café.txt.
+func unquoteFilename(name string) string {
+	if len(name) < 2 || name[0] != '"' || name[len(name)-1] != '"' {
+		return name
+	}
+	if unquoted, err := strconv.Unquote(name); err == nil {
+		return unquoted
+	}
+	return name[1 : len(name)-1]
+}
+
 func startsWith(str string, prefixes []string) bool {
 	for _, p := range prefixes {
-		return strings.HasPrefix(str, p)
+		if strings.HasPrefix(str, p) {
+			return true
+		}
 	}
 	return false
 }
 
-func existHunkHeader(lines []string, line string, lineIdx int) bool {
-	idx := lineIdx
-	l := len(lines)
-	for idx < l-3 {
-		if strings.HasPrefix(line, "diff") {
+// existHunkHeader reports whether a ---/+++/@@ sequence follows the
+// current line before the next file starts.
+func existHunkHeader(lr *lineReader) bool {
+	for i := 1; i+2 <= maxLookahead; i++ {
+		if strings.HasPrefix(lr.peek(i), "diff") {
 			return false
 		}
-		if strings.HasPrefix(lines[idx], oldFileNameHeader) &&
-			strings.HasPrefix(lines[idx+1], newFileNameHeader) &&
-			strings.HasPrefix(lines[idx+2], hunkHeaderPrefix) {
+		if strings.HasPrefix(lr.peek(i), oldFileNameHeader) &&
+			strings.HasPrefix(lr.peek(i+1), newFileNameHeader) &&
+			strings.HasPrefix(lr.peek(i+2), hunkHeaderPrefix) {
 
 			return true
 		}
-		idx++
 	}
 	return false
 }
@@ -1,10 +1,28 @@
 package diff2html
 
 import (
-	"fmt"
+	"reflect"
 	"testing"
 )
 
+// checkLines compares the lines of the only block of the only file in
+// files with want, given as prefix and content.
+func checkLines(t *testing.T, files []*File, want []string) {
+	t.Helper()
+	if len(files) != 1 || len(files[0].Blocks) != 1 {
+		t.Fatalf("expected one file with one block, got %d files", len(files))
+	}
+	lines := files[0].Blocks[0].Lines
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(want))
+	}
+	for i, line := range lines {
+		if line.Content != want[i] {
+			t.Errorf("line %d: got %q, want %q", i, line.Content, want[i])
+		}
+	}
+}
+
 func TestNew(t *testing.T) {
 	diff := "diff --git a/sample b/sample\n" +
 		"index 0000001..0ddf2ba\n" +
@@ -18,9 +36,18 @@ func TestNew(t *testing.T) {
 		DstPrefix: "",
 	}
 	d := newDiff(conf)
-	d.Parser(diff)
+	if err := d.Parser(diff); err != nil {
+		t.Fatal(err)
+	}
 
-	fmt.Println(d.Files)
+	checkLines(t, d.Files, []string{"-test", "+test1r"})
+	file := d.Files[0]
+	if file.OldName != "sample" || file.NewName != "sample" || !file.IsGiftDiff {
+		t.Errorf("got %q → %q, git %v", file.OldName, file.NewName, file.IsGiftDiff)
+	}
+	if file.ChecksumBefore != "0000001" || file.ChecksumAfter != "0ddf2ba" {
+		t.Errorf("checksums: got %s..%s", file.ChecksumBefore, file.ChecksumAfter)
+	}
 }
 
 func TestNew2(t *testing.T) {
@@ -36,9 +63,14 @@ func TestNew2(t *testing.T) {
 		DstPrefix: "",
 	}
 	d := newDiff(conf)
-	d.Parser(diff)
+	if err := d.Parser(diff); err != nil {
+		t.Fatal(err)
+	}
 
-	fmt.Println(d.Files)
+	checkLines(t, d.Files, []string{"-test", "+test1r"})
+	if d.Files[0].NewName != "sample" {
+		t.Errorf("got %q", d.Files[0].NewName)
+	}
 }
 
 func TestNew3(t *testing.T) {
@@ -53,7 +85,325 @@ func TestNew3(t *testing.T) {
 		DstPrefix: "",
 	}
 	d := newDiff(conf)
-	d.Parser(diff)
+	if err := d.Parser(diff); err != nil {
+		t.Fatal(err)
+	}
+
+	checkLines(t, d.Files, []string{"-test", "+test1r", "+test2r"})
+	file := d.Files[0]
+	if file.IsGiftDiff || file.Language != "javascript" {
+		t.Errorf("got git %v, language %q", file.IsGiftDiff, file.Language)
+	}
+	if file.AddedLines != 2 || file.DeletedLines != 1 {
+		t.Errorf("got +%d -%d", file.AddedLines, file.DeletedLines)
+	}
+	if lines := file.Blocks[0].Lines; lines[1].NewNumber != 1 || lines[2].NewNumber != 2 || lines[0].OldNumber != 1 {
+		t.Errorf("line numbers: got %+v", lines)
+	}
+}
+
+func Test_unquoteFilename(t *testing.T) {
+	tests := map[string]string{
+		`plain.txt`:                  "plain.txt",
+		`"a/caf\303\251.txt"`:        "a/café.txt",
+		`"tab\there \"quoted\".txt"`: "tab\there \"quoted\".txt",
+		`"bad\q"`:                    `bad\q`,
+	}
+	for name, want := range tests {
+		if got := unquoteFilename(name); got != want {
+			t.Errorf("unquoteFilename(%s) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestDiff_Parser_parseError(t *testing.T) {
+	header := "diff --git a/sample b/sample\n" +
+		"index 0000001..0ddf2ba\n" +
+		"--- a/sample\n" +
+		"+++ b/sample\n"
+	combinedHeader := "diff --cc sample\n" +
+		"index 0000001,0000002..0ddf2ba\n" +
+		"--- a/sample\n" +
+		"+++ b/sample\n"
+
+	tests := []struct {
+		name    string
+		input   string
+		line    int
+		content string
+	}{
+		{
+			name:    "overflow",
+			input:   header + "@@ -99999999999999999999 +1 @@\n-test\n+test1r\n",
+			line:    5,
+			content: "@@ -99999999999999999999 +1 @@",
+		},
+		{
+			name:    "malformed",
+			input:   header + "@@ -x +1 @@\n-test\n+test1r\n",
+			line:    5,
+			content: "@@ -x +1 @@",
+		},
+		{
+			name:    "truncated",
+			input:   header + "@@ -1,3 +1,3 @@\n a\n-b\n+c\n",
+			line:    5,
+			content: "@@ -1,3 +1,3 @@",
+		},
+		{
+			name:    "truncated before next hunk",
+			input:   header + "@@ -1,2 +1,2 @@\n-b\n+c\n@@ -10 +10 @@\n-d\n+e\n",
+			line:    5,
+			content: "@@ -1,2 +1,2 @@",
+		},
+		{
+			name:    "too many lines",
+			input:   header + "@@ -1,2 +1 @@\n+test1r\n+test2r\n",
+			line:    7,
+			content: "+test2r",
+		},
+		{
+			name:    "truncated combined",
+			input:   combinedHeader + "@@@ -1,3 -1,2 +1,2 @@@\n  a\n- b\n",
+			line:    5,
+			content: "@@@ -1,3 -1,2 +1,2 @@@",
+		},
+		{
+			name:    "too many combined lines",
+			input:   combinedHeader + "@@@ -1,2 -1 +1 @@@\n  a\n +b\n",
+			line:    7,
+			content: " +b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newDiff(Config{}).Parser(tt.input)
+			perr, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("got %v, want *ParseError", err)
+			}
+			if perr.Line != tt.line || perr.Content != tt.content {
+				t.Errorf("got line %d %q, want line %d %q", perr.Line, perr.Content, tt.line, tt.content)
+			}
+		})
+	}
+}
+
+func TestDiff_Parser_lineCounts(t *testing.T) {
+	diff := "--- a/sample\n" +
+		"+++ b/sample\n" +
+		"@@ -1,3 +1,3 @@\n" +
+		" a\n" +
+		"\n" +
+		"-b\n" +
+		"\\ No newline at end of file\n" +
+		"+c\n" +
+		"\\ No newline at end of file\n"
+
+	d := newDiff(Config{})
+	if err := d.Parser(diff); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Files) != 1 || len(d.Files[0].Blocks) != 1 {
+		t.Fatalf("got %+v", d.Files)
+	}
+	lines := d.Files[0].Blocks[0].Lines
+	if len(lines) != 4 {
+		t.Fatalf("lines: got %d, want 4", len(lines))
+	}
+	for i, want := range []bool{false, false, true, true} {
+		if lines[i].NoNewlineAtEOF != want {
+			t.Errorf("line %d: NoNewlineAtEOF %v, want %v", i, lines[i].NoNewlineAtEOF, want)
+		}
+	}
+}
+
+func TestDiff_Parser_gitLog(t *testing.T) {
+	diff := "commit 672ac43d9eb43ddeacf72aa9959ae421dba27d50\n" +
+		"Author: A <a@example.com>\n" +
+		"\n" +
+		"    second commit message\n" +
+		"\n" +
+		"diff --git a/f b/f\n" +
+		"index 422c2b7..0f7bc76 100644\n" +
+		"--- a/f\n" +
+		"+++ b/f\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		" a\n" +
+		"-b\n" +
+		"+c\n" +
+		"\n" +
+		"commit ddd43a4a73b91b52d942be2e1141a0e4d08c2d3c\n" +
+		"Author: A <a@example.com>\n" +
+		"\n" +
+		"    first commit message\n" +
+		"    -- \n" +
+		"\n" +
+		"diff --git a/f b/f\n" +
+		"new file mode 100644\n" +
+		"index 0000000..422c2b7\n" +
+		"--- /dev/null\n" +
+		"+++ b/f\n" +
+		"@@ -0,0 +1,2 @@\n" +
+		"+a\n" +
+		"+b\n"
+
+	d := newDiff(Config{})
+	if err := d.Parser(diff); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Files) != 2 {
+		t.Fatalf("files: got %d, want 2", len(d.Files))
+	}
+	for i, want := range []int{3, 2} {
+		if got := len(d.Files[i].Blocks[0].Lines); len(d.Files[i].Blocks) != 1 || got != want {
+			t.Errorf("file %d: got %d blocks with %d lines, want 1 with %d", i, len(d.Files[i].Blocks), got, want)
+		}
+	}
+}
+
+func TestDiff_Parser_noNewlineInContent(t *testing.T) {
+	diff := "--- a/sample\n" +
+		"+++ b/sample\n" +
+		"@@ -1 +1,2 @@\n" +
+		" a\n" +
+		"+\\ No newline at end of file\n"
 
-	fmt.Println(d.Files)
+	d := newDiff(Config{})
+	if err := d.Parser(diff); err != nil {
+		t.Fatal(err)
+	}
+	lines := d.Files[0].Blocks[0].Lines
+	if len(lines) != 2 || lines[1].Content != "+\\ No newline at end of file" || lines[0].NoNewlineAtEOF {
+		t.Errorf("got %+v %+v", lines[0], lines[len(lines)-1])
+	}
+}
+
+func TestDiff_Parser_lineEndings(t *testing.T) {
+	tests := []struct {
+		name      string
+		diff      string
+		normalize bool
+		want      []string // contents, with "\r\n" appended to CRLF lines
+	}{
+		{
+			"crlf lines",
+			"--- a/x\n+++ b/x\n@@ -1 +1 @@\n-a\n+a\r\n",
+			false,
+			[]string{"-a", "+a\r\n"},
+		},
+		{
+			"lone carriage return",
+			"--- a/x\n+++ b/x\n@@ -1 +1 @@\n-a\rb\n+a\rc\n",
+			false,
+			[]string{"-a\rb", "+a\rc"},
+		},
+		{
+			"diff converted to crlf",
+			"--- a/x\r\n+++ b/x\r\n@@ -1 +1 @@\r\n-a\r\n+a\r\r\n",
+			false,
+			[]string{"-a", "+a\r\n"},
+		},
+		{
+			"carriage return without newline",
+			"--- a/x\n+++ b/x\n@@ -1 +1 @@\n-a\n+a\r\n\\ No newline at end of file\n",
+			false,
+			[]string{"-a", "+a\r"},
+		},
+		{
+			"normalized",
+			"--- a/x\n+++ b/x\n@@ -1 +1 @@\n-a\rb\n+a\r\n",
+			true,
+			[]string{"-a", "+a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := Parse(tt.diff, Config{NormalizeLineEndings: tt.normalize})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, line := range files[0].Blocks[0].Lines {
+				content := line.Content
+				if line.CRLF {
+					content += "\r\n"
+				}
+				got = append(got, content)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiff_Parser_renameWithChanges(t *testing.T) {
+	diff := "diff --git a/old.txt b/new.txt\n" +
+		"similarity index 90%\n" +
+		"rename from old.txt\n" +
+		"rename to new.txt\n" +
+		"index 1111111..2222222 100644\n" +
+		"--- a/old.txt\n" +
+		"+++ b/new.txt\n" +
+		"@@ -1 +1 @@\n" +
+		"-a\n" +
+		"+b\n" +
+		"diff --git a/x.txt b/y.txt\n" +
+		"similarity index 100%\n" +
+		"rename from x.txt\n" +
+		"rename to y.txt\n"
+
+	d := newDiff(Config{})
+	if err := d.Parser(diff); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Files) != 2 {
+		t.Fatalf("files: got %d, want 2", len(d.Files))
+	}
+
+	file := d.Files[0]
+	if !file.IsRename || file.OldName != "old.txt" || file.NewName != "new.txt" {
+		t.Errorf("got %+v", file)
+	}
+	if len(file.Blocks) != 1 || file.Blocks[0].Header != "@@ -1 +1 @@" {
+		t.Fatalf("blocks: got %+v", file.Blocks)
+	}
+	if file.AddedLines != 1 || file.DeletedLines != 1 {
+		t.Errorf("lines: got +%d -%d", file.AddedLines, file.DeletedLines)
+	}
+
+	file = d.Files[1]
+	if !file.IsRename || file.OldName != "x.txt" || file.NewName != "y.txt" || len(file.Blocks) != 0 {
+		t.Errorf("got %+v", file)
+	}
+}
+
+func TestDiff_Parser_combinedLineTypes(t *testing.T) {
+	diff := "diff --cc config.yaml\n" +
+		"index aaaaaaa,bbbbbbb..ccccccc\n" +
+		"--- a/config.yaml\n" +
+		"+++ b/config.yaml\n" +
+		"@@@ -1,3 -1,2 +1,3 @@@\n" +
+		"  name: app\n" +
+		"- port: 8080\n" +
+		" -port: 9090\n" +
+		"++port: 8443\n" +
+		" +tls: true\n"
+	files, err := Parse(diff, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []LineType{LineContext, LineDelete, LineDelete, LineInsert, LineInsert}
+	lines := files[0].Blocks[0].Lines
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(want))
+	}
+	for i, line := range lines {
+		if line.Type != want[i] {
+			t.Errorf("%q: got %s, want %s", line.Content, line.Type, want[i])
+		}
+	}
 }
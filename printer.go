@@ -0,0 +1,393 @@
+package diff2html
+
+import (
+	"bytes"
+	"errors"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	documentHeaderTemplate          = template.Must(template.New("document-header").Parse(documentHeader))
+	genericColumnLineNumberTemplate = template.Must(template.New("generic-column-line-number").Parse(genericColumnLineNumber))
+	genericEmptyDiffTemplate        = template.Must(template.New("generic-empty-diff").Parse(genericEmptyDiff))
+	genericFilePathTemplate         = template.Must(template.New("generic-file-path").Parse(genericFilePath))
+	genericLineTemplate             = template.Must(template.New("generic-line").Parse(genericLine))
+	iconFileTemplate                = template.Must(template.New("icon-file").Parse(iconFile))
+	tagFileAddedTemplate            = template.Must(template.New("tag-file-added").Parse(tagFileAdded))
+	tagFileChangedTemplate          = template.Must(template.New("tag-file-changed").Parse(tagFileChanged))
+	tagFileDeletedTemplate          = template.Must(template.New("tag-file-deleted").Parse(tagFileDeleted))
+	tagFileRenamedTemplate          = template.Must(template.New("tag-file-renamed").Parse(tagFileRenamed))
+)
+
+// writeDocumentHTML wraps the html written by writeBody in a complete
+// html document with the stylesheet inlined.
+func writeDocumentHTML(w io.Writer, title string, writeBody func(io.Writer) error) error {
+	err := documentHeaderTemplate.Execute(w, struct {
+		Title string
+		CSS   template.CSS
+	}{
+		Title: title,
+		CSS:   template.CSS(CSS),
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeBody(w); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, documentFooter)
+	return err
+}
+
+// writeWrapperHTML writes the wrapper around the files, rendering each
+// one with writeFile, along with its anchor, as it goes. Context from
+// conf.Source is added to a copy of each file, so files are not modified;
+// a file whose source does not match the diff is written as it is.
+func writeWrapperHTML(w io.Writer, files []*File, conf RenderConfig, writeFile func(w io.Writer, file *File, id string) error) error {
+	if _, err := io.WriteString(w, genericWrapperOpen); err != nil {
+		return err
+	}
+	anchors := fileAnchors(files)
+	if conf.Summary {
+		if err := writeSummaryHTML(w, files, anchors); err != nil {
+			return err
+		}
+	}
+	for _, file := range files {
+		id := anchors[file]
+		if conf.Source != nil && conf.ExpandContext != 0 {
+			expanded := *file
+			err := ExpandContext(&expanded, conf.Source, conf.ExpandContext)
+			if err == nil {
+				file = &expanded
+			} else if !errors.Is(err, ErrSourceMismatch) {
+				return err
+			}
+		}
+		if err := writeFile(w, file, id); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, genericWrapperClose)
+	return err
+}
+
+func makePathHTML(file *File) (string, error) {
+	iconHTML, err := makeIconHTML()
+	if err != nil {
+		return "", err
+	}
+	tagHTML, err := makeTagHTML(file)
+	if err != nil {
+		return "", err
+	}
+
+	buf := &bytes.Buffer{}
+	err = genericFilePathTemplate.Execute(buf, struct {
+		FileDiffName string
+		FileIcon     template.HTML
+		FileTag      template.HTML
+	}{
+		FileDiffName: getDiffName(file),
+		FileIcon:     template.HTML(iconHTML),
+		FileTag:      template.HTML(tagHTML),
+	})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func makeIconHTML() (string, error) {
+	buf := &bytes.Buffer{}
+	err := iconFileTemplate.Execute(buf, struct{}{})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func makeTagHTML(file *File) (string, error) {
+	tagTemplate := tagFileChangedTemplate
+	if file.IsRename {
+		tagTemplate = tagFileRenamedTemplate
+	} else if file.IsCopy {
+		tagTemplate = tagFileRenamedTemplate
+	} else if file.IsNew {
+		tagTemplate = tagFileAddedTemplate
+	} else if file.IsDeleted {
+		tagTemplate = tagFileDeletedTemplate
+	} else if file.NewName != file.OldName {
+		tagTemplate = tagFileRenamedTemplate
+	}
+	buf := &bytes.Buffer{}
+	err := tagTemplate.Execute(buf, struct{}{})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// separatePrefix splits the one or, in combined diffs, two column prefix
+// off a line of the diff.
+func separatePrefix(isCombined bool, line string) (string, string) {
+	size := 1
+	if isCombined {
+		size = 2
+	}
+	if size > len(line) {
+		size = len(line)
+	}
+	return line[:size], line[size:]
+}
+
+// contextPrefix blanks the prefix of a changed line shown as unchanged.
+func contextPrefix(prefix string) string {
+	return strings.Repeat(" ", len(prefix))
+}
+
+// getHTMLID returns the anchor of file, a slug of its path such as
+// "d2h-src-main-go". It does not change when the file is renamed in a
+// later version of the change; fileAnchors makes it unique.
+func getHTMLID(file *File) string {
+	name := unifyPath(file.NewName)
+	if name == "" || isDevNullName(name) {
+		name = unifyPath(file.OldName)
+	}
+
+	slug := []byte{}
+	for _, c := range []byte(strings.ToLower(name)) {
+		if ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') {
+			slug = append(slug, c)
+		} else if len(slug) > 0 && slug[len(slug)-1] != '-' {
+			slug = append(slug, '-')
+		}
+	}
+	if id := strings.TrimSuffix(string(slug), "-"); id != "" {
+		return "d2h-" + id
+	}
+	return "d2h-file"
+}
+
+// fileAnchors returns the anchor of every file, numbering files whose
+// anchors would otherwise be the same, as in "d2h-main-go-2".
+func fileAnchors(files []*File) map[*File]string {
+	anchors := make(map[*File]string, len(files))
+	used := map[string]bool{}
+	next := map[string]int{} // the next suffix to try after a slug
+	for _, file := range files {
+		slug := getHTMLID(file)
+		id := slug
+		for used[id] {
+			if next[slug] < 2 {
+				next[slug] = 2
+			}
+			id = slug + "-" + strconv.Itoa(next[slug])
+			next[slug]++
+		}
+		used[id] = true
+		anchors[file] = id
+	}
+	return anchors
+}
+
+func getDiffName(file *File) string {
+	oldFilename := unifyPath(file.OldName)
+	newFilename := unifyPath(file.NewName)
+
+	if oldFilename != "" && newFilename != "" && oldFilename != newFilename && !isDevNullName(oldFilename) && !isDevNullName(newFilename) {
+		return oldFilename + " → " + newFilename
+	} else if newFilename != "" && !isDevNullName(newFilename) {
+		return newFilename
+	} else if oldFilename != "" {
+		return oldFilename
+	}
+	return "unknown/file/path"
+}
+
+func unifyPath(path string) string {
+	if path != "" {
+		return strings.Replace(path, "\\", "/", 1)
+	}
+	return path
+}
+
+// isDevNullName reports whether a file name is the /dev/null that stands
+// for the missing side of an added or deleted file.
+func isDevNullName(str string) bool {
+	return str == "/dev/null" || str == "dev/null"
+}
+
+type Highlight struct {
+	First  HighlightPart
+	Second HighlightPart
+}
+
+type HighlightPart struct {
+	Prefix string
+	Line   template.HTML
+}
+
+// diffHighlight highlights the words that changed between two paired
+// lines. When syntax is set, each side is syntax highlighted as well.
+func diffHighlight(diffLine1, diffLine2 string, isCombined bool, style DiffStyle, whitespace IgnoreWhitespace, syntax SyntaxHighlighter, language string) Highlight {
+	linePrefix1, unprefixedLine1 := separatePrefix(isCombined, diffLine1)
+	linePrefix2, unprefixedLine2 := separatePrefix(isCombined, diffLine2)
+
+	var first, second []diffmatchpatch.Diff
+	if whitespace.ignores() {
+		first, second = whitespaceDiff(unprefixedLine1, unprefixedLine2, whitespace, style)
+	} else {
+		differ := diffmatchpatch.New()
+		diffs := differ.DiffMain(unprefixedLine1, unprefixedLine2, true)
+		if style != DiffStyleChar {
+			diffs = differ.DiffCleanupSemantic(diffs)
+		}
+
+		for _, part := range diffs {
+			if part.Type != diffmatchpatch.DiffInsert {
+				first = append(first, part)
+			}
+			if part.Type != diffmatchpatch.DiffDelete {
+				second = append(second, part)
+			}
+		}
+	}
+
+	return Highlight{
+		First: HighlightPart{
+			Prefix: linePrefix1,
+			Line:   mergeHTML(first, syntaxTokens(syntax, language, unprefixedLine1)),
+		},
+		Second: HighlightPart{
+			Prefix: linePrefix2,
+			Line:   mergeHTML(second, syntaxTokens(syntax, language, unprefixedLine2)),
+		},
+	}
+}
+
+// contentHTML renders the content of a line that is not paired with
+// another one.
+func contentHTML(content string, syntax SyntaxHighlighter, language string) template.HTML {
+	parts := []diffmatchpatch.Diff{{Type: diffmatchpatch.DiffEqual, Text: content}}
+	return mergeHTML(parts, syntaxTokens(syntax, language, content))
+}
+
+// lineHTML appends the markers of its line ending to the content of
+// line: with cr, the carriage return as deleted or inserted, and the no
+// newline glyph.
+func lineHTML(content template.HTML, line *Line, cr bool) template.HTML {
+	if cr && line.Type == LineDelete {
+		content += crDeleted
+	} else if cr {
+		content += crInserted
+	}
+	if line.NoNewlineAtEOF {
+		content += noNewline
+	}
+	return content
+}
+
+// lineEndings counts the lines of each version of a file in the diff, and
+// how many of them end with CRLF.
+type lineEndings struct {
+	// ignored is set when the whitespace mode ignores carriage returns at
+	// the end of lines, so that none is marked. Every mode ignores them,
+	// as git diff does.
+	ignored bool
+
+	oldLines, oldCRLF int
+	newLines, newCRLF int
+}
+
+func lineEndingsOf(file *File, whitespace IgnoreWhitespace) lineEndings {
+	e := lineEndings{ignored: whitespace.ignores()}
+	for _, block := range file.Blocks {
+		for _, line := range block.Lines {
+			cr := 0
+			if line.CRLF {
+				cr = 1
+			}
+			if line.Type != LineInsert {
+				e.oldLines++
+				e.oldCRLF += cr
+			}
+			if line.Type != LineDelete {
+				e.newLines++
+				e.newCRLF += cr
+			}
+		}
+	}
+	return e
+}
+
+// crChanged reports whether the carriage return of a deleted or inserted
+// line without a counterpart is a change, because the other version
+// mostly ends its lines without one.
+func (e lineEndings) crChanged(line *Line) bool {
+	if e.ignored || !line.CRLF {
+		return false
+	}
+	switch line.Type {
+	case LineDelete:
+		return e.newLines > 0 && 2*e.newCRLF < e.newLines
+	case LineInsert:
+		return e.oldLines > 0 && 2*e.oldCRLF < e.oldLines
+	}
+	return false
+}
+
+// pairHTML finishes the highlighted contents of a paired deleted and
+// inserted line. A carriage return ending only one of them is marked.
+func (e lineEndings) pairHTML(highlight Highlight, oldLine, newLine *Line) (template.HTML, template.HTML) {
+	changed := !e.ignored && oldLine.CRLF != newLine.CRLF
+	return lineHTML(highlight.First.Line, oldLine, changed && oldLine.CRLF),
+		lineHTML(highlight.Second.Line, newLine, changed && newLine.CRLF)
+}
+
+// mergeHTML renders a line split both into diff parts and into syntax
+// tokens of the same text. Tokens that cross an ins or del boundary are
+// split, so the elements always nest.
+func mergeHTML(parts []diffmatchpatch.Diff, tokens []SyntaxToken) template.HTML {
+	buf := &strings.Builder{}
+	token, offset := 0, 0
+	for _, part := range parts {
+		elemType := ""
+		if part.Type == diffmatchpatch.DiffInsert {
+			elemType = "ins"
+		} else if part.Type == diffmatchpatch.DiffDelete {
+			elemType = "del"
+		}
+		if elemType != "" {
+			buf.WriteString("<" + elemType + ">")
+		}
+		for rest := len(part.Text); rest > 0 && token < len(tokens); {
+			text := tokens[token].Text[offset:]
+			if len(text) > rest {
+				text = text[:rest]
+			}
+			if class := tokens[token].Class; class != "" {
+				buf.WriteString(`<span class="` + template.HTMLEscapeString(class) + `">` + template.HTMLEscapeString(text) + "</span>")
+			} else {
+				buf.WriteString(template.HTMLEscapeString(text))
+			}
+			offset += len(text)
+			rest -= len(text)
+			if offset == len(tokens[token].Text) {
+				token++
+				offset = 0
+			}
+		}
+		if elemType != "" {
+			buf.WriteString("</" + elemType + ">")
+		}
+	}
+	return template.HTML(buf.String())
+}
@@ -2,236 +2,156 @@ package diff2html
 
 import (
 	"bytes"
-	"github.com/sergi/go-diff/diffmatchpatch"
 	"html/template"
+	"io"
 	"math"
-	"regexp"
-	"strconv"
-	"strings"
 )
 
 var (
-	genericColumnLineNumberTemplate = template.Must(template.New("generic-column-line-number").Parse(genericColumnLineNumber))
-	genericEmptyDiffTemplate        = template.Must(template.New("generic-empty-diff").Parse(genericEmptyDiff))
-	genericFilePathTemplate         = template.Must(template.New("generic-file-path").Parse(genericFilePath))
-	genericLineTemplate             = template.Must(template.New("generic-line").Parse(genericLine))
-	genericWrapperTemplate          = template.Must(template.New("generic-wrapper").Parse(genericWrapper))
-	iconFileTemplate                = template.Must(template.New("icon-file").Parse(iconFile))
-	sideBySideFileDiffTemplate      = template.Must(template.New("side-by-side-file-diff").Parse(sideBySideFileDiff))
-	tagFileAddedTemplate            = template.Must(template.New("tag-file-added").Parse(tagFileAdded))
-	tagFileChangedTemplate          = template.Must(template.New("tag-file-changed").Parse(tagFileChanged))
-	tagFileDeletedTemplate          = template.Must(template.New("tag-file-deleted").Parse(tagFileDeleted))
-	tagFileRenamedTemplate          = template.Must(template.New("tag-file-renamed").Parse(tagFileRenamed))
+	sideBySideFileDiffTemplate = template.Must(template.New("side-by-side-file-diff").Parse(sideBySideFileDiff))
 )
 
-type fileHTML struct {
-	Left  string
-	Right string
-}
-
-func newSideBySide() *sideBySidePrinter {
-	return &sideBySidePrinter{}
+func newSideBySide(conf RenderConfig) *sideBySidePrinter {
+	return &sideBySidePrinter{conf: conf, classes: conf.ClassNames.withDefaults()}
 }
 
 type sideBySidePrinter struct {
+	conf    RenderConfig
+	classes ClassNames
+	links   lineLinker      // of the file being written
+	notes   fileAnnotations // of the file being written
+	endings lineEndings     // of the file being written
 }
 
 func (p *sideBySidePrinter) GenerateSideBySideHTML(files []*File) (string, error) {
-	content := ""
-	for _, file := range files {
-		var fileHTML *fileHTML
-		var err error
-		if len(file.Blocks) > 0 {
-			fileHTML, err = p.genSideBySideFileHTML(file)
-			if err != nil {
-				return "", err
-			}
-		} else {
-			fileHTML, err = p.genEmptyDiff()
-			if err != nil {
-				return "", err
-			}
-		}
-
-		dh, err := p.makeDiffHTML(file, fileHTML)
-		if err != nil {
-			return "", err
-		}
-		content += dh
-		content += "\n"
-	}
-
 	buf := &bytes.Buffer{}
-	err := genericWrapperTemplate.Execute(buf, struct {
-		Content template.HTML
-	}{
-		Content: template.HTML(content),
-	})
-	if err != nil {
+	if err := p.render(buf, files); err != nil {
 		return "", err
 	}
-
 	return buf.String(), nil
 }
 
-func (p *sideBySidePrinter) makeDiffHTML(file *File, diffs *fileHTML) (string, error) {
+func (p *sideBySidePrinter) render(w io.Writer, files []*File) error {
+	return writeWrapperHTML(w, files, p.conf, p.writeDiffHTML)
+}
+
+// writeDiffHTML writes the html of one file. The two sides are separate
+// tables, so they are collected per file before being written to w.
+func (p *sideBySidePrinter) writeDiffHTML(w io.Writer, file *File, id string) error {
+	p.links = lineLinker{file: file, id: id, resolve: p.conf.LinkResolver}
+	p.notes = annotationsOf(file, p.conf.Annotations)
+	p.endings = lineEndingsOf(file, p.conf.IgnoreWhitespace)
+	left := &bytes.Buffer{}
+	right := &bytes.Buffer{}
+	if len(file.Blocks) > 0 {
+		if err := p.genSideBySideFileHTML(left, right, file); err != nil {
+			return err
+		}
+	} else {
+		if err := p.genEmptyDiff(left); err != nil {
+			return err
+		}
+	}
 
-	pathHTML, err := p.makePathHTML(file)
+	pathHTML, err := makePathHTML(file)
 	if err != nil {
-		return "", err
+		return err
+	}
+	expandAllHTML, err := makeExpandAllHTML(file, p.conf)
+	if err != nil {
+		return err
 	}
 
-	buf := &bytes.Buffer{}
-	err = sideBySideFileDiffTemplate.Execute(buf, struct {
+	return sideBySideFileDiffTemplate.Execute(w, struct {
 		FileHTMLID string
 		FilePath   template.HTML
+		ExpandAll  template.HTML
 		Language   string
 		Left       template.HTML
 		Right      template.HTML
 	}{
-		FileHTMLID: getHTMLID(file),
+		FileHTMLID: id,
 		FilePath:   template.HTML(pathHTML),
+		ExpandAll:  template.HTML(expandAllHTML),
 		Language:   file.Language,
-		Left:       template.HTML(diffs.Left),
-		Right:      template.HTML(diffs.Right),
+		Left:       template.HTML(left.String()),
+		Right:      template.HTML(right.String()),
 	})
-	if err != nil {
-		return "", err
-	}
-	return buf.String(), nil
 }
 
-func (p *sideBySidePrinter) makePathHTML(file *File) (string, error) {
-	iconHTML, err := p.makeIconHTML()
-	if err != nil {
-		return "", err
-	}
-	tagHTML, err := p.makeTagHTML(file)
-	if err != nil {
-		return "", err
-	}
-
-	buf := &bytes.Buffer{}
-	err = genericFilePathTemplate.Execute(buf, struct {
-		FileDiffName string
-		FileIcon     template.HTML
-		FileTag      template.HTML
-	}{
-		FileDiffName: getDiffName(file),
-		FileIcon:     template.HTML(iconHTML),
-		FileTag:      template.HTML(tagHTML),
-	})
-	if err != nil {
-		return "", err
-	}
-	return buf.String(), nil
-}
-
-func (p *sideBySidePrinter) makeIconHTML() (string, error) {
-	buf := &bytes.Buffer{}
-	err := iconFileTemplate.Execute(buf, struct{}{})
-	if err != nil {
-		return "", err
-	}
-	return buf.String(), nil
-}
-
-func (p *sideBySidePrinter) makeTagHTML(file *File) (string, error) {
-	tagTemplate := tagFileChangedTemplate
-	if file.IsRename {
-		tagTemplate = tagFileRenamedTemplate
-	} else if file.IsCopy {
-		tagTemplate = tagFileRenamedTemplate
-	} else if file.IsNew {
-		tagTemplate = tagFileAddedTemplate
-	} else if file.IsDeleted {
-		tagTemplate = tagFileDeletedTemplate
-	} else if file.NewName != file.OldName {
-		tagTemplate = tagFileRenamedTemplate
-	}
-	buf := &bytes.Buffer{}
-	err := tagTemplate.Execute(buf, struct{}{})
-	if err != nil {
-		return "", err
-	}
-	return buf.String(), nil
-}
-
-func (p *sideBySidePrinter) makeSideHTML(blockHeader string) (string, error) {
-	buf := &bytes.Buffer{}
-	err := genericColumnLineNumberTemplate.Execute(buf, struct {
+func (p *sideBySidePrinter) makeSideHTML(w io.Writer, blockHeader string) error {
+	return genericColumnLineNumberTemplate.Execute(w, struct {
 		BlockHeader  string
 		Type         string
 		LineClass    string
 		ContentClass string
 	}{
 		BlockHeader:  blockHeader,
-		Type:         info,
+		Type:         p.classes.Info,
 		LineClass:    "d2h-code-side-linenumber",
 		ContentClass: "d2h-code-side-line",
 	})
-	if err != nil {
-		return "", err
-	}
-	return buf.String(), nil
 }
 
-func (p *sideBySidePrinter) genSideBySideFileHTML(file *File) (*fileHTML, error) {
-	var err error
+func (p *sideBySidePrinter) makeCollapseHTML(w io.Writer, id, hidden int) error {
+	return writeCollapseOpen(w, p.conf, id, hidden, p.classes.Info, "d2h-code-side-linenumber", "d2h-code-side-line")
+}
 
-	fileHTML := &fileHTML{
-		Left:  "",
-		Right: "",
-	}
+func (p *sideBySidePrinter) genSideBySideFileHTML(left, right io.Writer, file *File) error {
+	collapseID := 0
 	for _, block := range file.Blocks {
-		if fileHTML.Left, err = p.makeSideHTML(block.Header); err != nil {
-			return nil, err
+		if err := p.makeSideHTML(left, block.Header); err != nil {
+			return err
 		}
-		if fileHTML.Right, err = p.makeSideHTML(""); err != nil {
-			return nil, err
+		if err := p.makeSideHTML(right, ""); err != nil {
+			return err
 		}
 
 		oldLines := make([]*Line, 0)
 		newLines := make([]*Line, 0)
 
+		matcher := newLineMatcher(p.conf, file.IsCombined)
 		processChangeBlock := func() error {
-			// conf.matching == "none" only
-			oldLen := len(oldLines)
-			newLen := len(newLines)
-			common := int(math.Min(float64(oldLen), float64(newLen)))
-			max := int(math.Max(float64(oldLen), float64(newLen)))
-
-			for i := 0; i < common; i++ {
-				oldLine := &Line{Content: ""}
-				if oldLen > i {
-					oldLine = oldLines[i]
-				}
-				newLine := &Line{Content: ""}
-				if newLen > i {
-					newLine = newLines[i]
+			for _, group := range matcher.match(oldLines, newLines) {
+				if !group.highlight {
+					if err := p.processLines(left, right, file, group.oldLines, group.newLines); err != nil {
+						return err
+					}
+					continue
 				}
-				highlight := diffHighlight(oldLine.Content, newLine.Content, file.IsCombined)
-				left, err := p.genSingleLineHTML(file.IsCombined, deletes, oldLine.OldNumber, highlight.First.Line, highlight.First.Prefix)
-				if err != nil {
-					return err
-				}
-				fileHTML.Left += left
-				right, err := p.genSingleLineHTML(file.IsCombined, inserts, newLine.NewNumber, highlight.Second.Line, highlight.Second.Prefix)
-				if err != nil {
-					return err
+
+				oldLen := len(group.oldLines)
+				newLen := len(group.newLines)
+				common := int(math.Min(float64(oldLen), float64(newLen)))
+				max := int(math.Max(float64(oldLen), float64(newLen)))
+
+				for i := 0; i < common; i++ {
+					oldLine := group.oldLines[i]
+					newLine := group.newLines[i]
+					if showsAsContext(p.conf, file, oldLine, newLine) {
+						if err := p.genContextPairHTML(left, right, file, oldLine, newLine); err != nil {
+							return err
+						}
+						continue
+					}
+					highlight := diffHighlight(oldLine.Content, newLine.Content, file.IsCombined, p.conf.DiffStyle, p.conf.IgnoreWhitespace, p.conf.SyntaxHighlighter, file.Language)
+					oldHTML, newHTML := p.endings.pairHTML(highlight, oldLine, newLine)
+					if err := p.genSingleLineHTML(left, file.IsCombined, p.classes.line(LineDelete, true), SideOld, oldLine.OldNumber, oldHTML, highlight.First.Prefix); err != nil {
+						return err
+					}
+					if err := p.genSingleLineHTML(right, file.IsCombined, p.classes.line(LineInsert, true), SideNew, newLine.NewNumber, newHTML, highlight.Second.Prefix); err != nil {
+						return err
+					}
+					if err := p.writeAnnotations(left, right, oldLine.OldNumber, newLine.NewNumber); err != nil {
+						return err
+					}
 				}
-				fileHTML.Right += right
-			}
 
-			if max > common {
-				oldSlice := oldLines[common:]
-				newSlice := newLines[common:]
-				tmpHtml, err := p.processLines(file.IsCombined, oldSlice, newSlice)
-				if err != nil {
-					return err
+				if max > common {
+					if err := p.processLines(left, right, file, group.oldLines[common:], group.newLines[common:]); err != nil {
+						return err
+					}
 				}
-				fileHTML.Left += tmpHtml.Left
-				fileHTML.Right += tmpHtml.Right
 			}
 
 			oldLines = make([]*Line, 0)
@@ -239,61 +159,78 @@ func (p *sideBySidePrinter) genSideBySideFileHTML(file *File) (*fileHTML, error)
 			return nil
 		}
 
-		for _, line := range block.Lines {
-			prefix := string(line.Content[0])
-			escapedLine := line.Content[1:]
+		runs := collapsedRuns(block.Lines, p.conf.CollapseContext)
+		collapseEnd := -1
+		for i, line := range block.Lines {
+			prefix, content := separatePrefix(file.IsCombined, line.Content)
+			escapedLine := lineHTML(contentHTML(content, p.conf.SyntaxHighlighter, file.Language), line, p.endings.crChanged(line))
 
-			if line.Type != inserts && (len(newLines) > 0 || (line.Type != deletes && len(oldLines) > 0)) {
+			if line.Type != LineInsert && (len(newLines) > 0 || (line.Type != LineDelete && len(oldLines) > 0)) {
 				if err := processChangeBlock(); err != nil {
-					return nil, err
+					return err
+				}
+			}
+
+			if end, ok := runs[i]; ok {
+				collapseID++
+				collapseEnd = end
+				if err := p.makeCollapseHTML(left, collapseID, end-i); err != nil {
+					return err
+				}
+				if err := p.makeCollapseHTML(right, collapseID, end-i); err != nil {
+					return err
 				}
 			}
 
-			if line.Type == context {
-				left, err := p.genSingleLineHTML(file.IsCombined, line.Type, line.OldNumber, escapedLine, prefix)
-				if err != nil {
-					return nil, err
+			if line.Type == LineContext {
+				if err := p.genSingleLineHTML(left, file.IsCombined, p.classes.line(line.Type, false), SideOld, line.OldNumber, escapedLine, prefix); err != nil {
+					return err
+				}
+				if err := p.genSingleLineHTML(right, file.IsCombined, p.classes.line(line.Type, false), SideNew, line.NewNumber, escapedLine, prefix); err != nil {
+					return err
+				}
+				if err := p.writeAnnotations(left, right, line.OldNumber, line.NewNumber); err != nil {
+					return err
 				}
-				fileHTML.Left += left
-				right, err := p.genSingleLineHTML(file.IsCombined, line.Type, line.NewNumber, escapedLine, prefix)
-				if err != nil {
-					return nil, err
+				if i+1 == collapseEnd {
+					if err := writeCollapseClose(left); err != nil {
+						return err
+					}
+					if err := writeCollapseClose(right); err != nil {
+						return err
+					}
 				}
-				fileHTML.Right += right
-			} else if line.Type == inserts && len(oldLines) == 0 {
-				left, err := p.genSingleLineHTML(file.IsCombined, context, 0, "", "")
-				if err != nil {
-					return nil, err
+			} else if line.Type == LineInsert && len(oldLines) == 0 {
+				if err := p.genSingleLineHTML(left, file.IsCombined, p.classes.line(LineContext, false), SideOld, 0, "", ""); err != nil {
+					return err
 				}
-				fileHTML.Left += left
-				right, err := p.genSingleLineHTML(file.IsCombined, line.Type, line.NewNumber, escapedLine, prefix)
-				if err != nil {
-					return nil, err
+				if err := p.genSingleLineHTML(right, file.IsCombined, p.classes.line(line.Type, false), SideNew, line.NewNumber, escapedLine, prefix); err != nil {
+					return err
+				}
+				if err := p.writeAnnotations(left, right, 0, line.NewNumber); err != nil {
+					return err
 				}
-				fileHTML.Right += right
-			} else if line.Type == deletes {
+			} else if line.Type == LineDelete {
 				oldLines = append(oldLines, line)
-			} else if line.Type == inserts && len(oldLines) > 0 {
+			} else if line.Type == LineInsert && len(oldLines) > 0 {
 				newLines = append(newLines, line)
 			} else {
 				// console.error('unknown state in html side-by-side generator');
 				if err := processChangeBlock(); err != nil {
-					return nil, err
+					return err
 				}
 			}
 		}
 
 		if err := processChangeBlock(); err != nil {
-			return nil, err
+			return err
 		}
 	}
 
-	return fileHTML, nil
+	return nil
 }
 
-func (p *sideBySidePrinter) processLines(isCombined bool, oldLines, newLines []*Line) (*fileHTML, error) {
-	fileHTML := &fileHTML{Left: "", Right: ""}
-
+func (p *sideBySidePrinter) processLines(left, right io.Writer, file *File, oldLines, newLines []*Line) error {
 	oldLinesLen := len(oldLines)
 	newLinesLen := len(newLines)
 	maxLinesNumber := int(math.Max(float64(oldLinesLen), float64(newLinesLen)))
@@ -307,229 +244,124 @@ func (p *sideBySidePrinter) processLines(isCombined bool, oldLines, newLines []*
 			newLine = newLines[i]
 		}
 
-		var oldContent string
-		var newContent string
+		var oldContent template.HTML
+		var newContent template.HTML
 		var oldPrefix string
 		var newPrefix string
 
 		if oldLine != nil {
-			oldContent = oldLine.Content[1:]
-			oldPrefix = oldLine.Content[0:1]
+			var content string
+			oldPrefix, content = separatePrefix(file.IsCombined, oldLine.Content)
+			oldContent = lineHTML(contentHTML(content, p.conf.SyntaxHighlighter, file.Language), oldLine, p.endings.crChanged(oldLine))
 		}
 		if newLine != nil {
-			newContent = newLine.Content[1:]
-			newPrefix = newLine.Content[0:1]
+			var content string
+			newPrefix, content = separatePrefix(file.IsCombined, newLine.Content)
+			newContent = lineHTML(contentHTML(content, p.conf.SyntaxHighlighter, file.Language), newLine, p.endings.crChanged(newLine))
 		}
 
 		if oldLine != nil && newLine != nil {
-			left, err := p.genSingleLineHTML(isCombined, oldLine.Type, oldLine.OldNumber, oldContent, oldPrefix)
-			if err != nil {
-				return nil, err
+			if err := p.genSingleLineHTML(left, file.IsCombined, p.classes.line(oldLine.Type, false), SideOld, oldLine.OldNumber, oldContent, oldPrefix); err != nil {
+				return err
 			}
-			fileHTML.Left += left
-			right, err := p.genSingleLineHTML(isCombined, newLine.Type, newLine.NewNumber, newContent, newPrefix)
-			if err != nil {
-				return nil, err
+			if err := p.genSingleLineHTML(right, file.IsCombined, p.classes.line(newLine.Type, false), SideNew, newLine.NewNumber, newContent, newPrefix); err != nil {
+				return err
 			}
-			fileHTML.Right += right
 		} else if oldLine != nil {
-			left, err := p.genSingleLineHTML(isCombined, oldLine.Type, oldLine.OldNumber, oldContent, oldPrefix)
-			if err != nil {
-				return nil, err
+			if err := p.genSingleLineHTML(left, file.IsCombined, p.classes.line(oldLine.Type, false), SideOld, oldLine.OldNumber, oldContent, oldPrefix); err != nil {
+				return err
 			}
-			fileHTML.Left += left
-			right, err := p.genSingleLineHTML(isCombined, context, 0, "", "")
-			if err != nil {
-				return nil, err
+			if err := p.genSingleLineHTML(right, file.IsCombined, p.classes.line(LineContext, false), SideNew, 0, "", ""); err != nil {
+				return err
 			}
-			fileHTML.Right += right
 		} else if newLine != nil {
-			left, err := p.genSingleLineHTML(isCombined, context, 0, "", "")
-			if err != nil {
-				return nil, err
+			if err := p.genSingleLineHTML(left, file.IsCombined, p.classes.line(LineContext, false), SideOld, 0, "", ""); err != nil {
+				return err
 			}
-			fileHTML.Left += left
-			right, err := p.genSingleLineHTML(isCombined, newLine.Type, newLine.NewNumber, newContent, newPrefix)
-			if err != nil {
-				return nil, err
+			if err := p.genSingleLineHTML(right, file.IsCombined, p.classes.line(newLine.Type, false), SideNew, newLine.NewNumber, newContent, newPrefix); err != nil {
+				return err
 			}
-			fileHTML.Right += right
 		} else {
 			// console.error('How did it get here?');
 		}
+
+		oldNumber, newNumber := 0, 0
+		if oldLine != nil {
+			oldNumber = oldLine.OldNumber
+		}
+		if newLine != nil {
+			newNumber = newLine.NewNumber
+		}
+		if err := p.writeAnnotations(left, right, oldNumber, newNumber); err != nil {
+			return err
+		}
 	}
 
-	return fileHTML, nil
+	return nil
 }
 
-func (p *sideBySidePrinter) genSingleLineHTML(isCombined bool, lineType string, num int, content string, possiblePrefix string) (string, error) {
-	lineWithoutPrefix := content
-	prefix := possiblePrefix
-
-	if prefix == "" && content != "" {
-		prefix, lineWithoutPrefix = separatePrefix(isCombined, content)
+// genContextPairHTML shows a deleted and an inserted line that differ only
+// in ignored whitespace as an unchanged line.
+func (p *sideBySidePrinter) genContextPairHTML(left, right io.Writer, file *File, oldLine, newLine *Line) error {
+	lineClass := p.classes.line(LineContext, false)
+	// The whitespace mode ignores carriage returns, so none is marked.
+	oldPrefix, content := separatePrefix(file.IsCombined, oldLine.Content)
+	oldContent := lineHTML(contentHTML(content, p.conf.SyntaxHighlighter, file.Language), oldLine, false)
+	if err := p.genSingleLineHTML(left, file.IsCombined, lineClass, SideOld, oldLine.OldNumber, oldContent, contextPrefix(oldPrefix)); err != nil {
+		return err
 	}
-
-	lineNumberStr := ""
-	if num > 0 {
-		lineNumberStr = strconv.Itoa(num)
+	newPrefix, content := separatePrefix(file.IsCombined, newLine.Content)
+	newContent := lineHTML(contentHTML(content, p.conf.SyntaxHighlighter, file.Language), newLine, false)
+	if err := p.genSingleLineHTML(right, file.IsCombined, lineClass, SideNew, newLine.NewNumber, newContent, contextPrefix(newPrefix)); err != nil {
+		return err
 	}
+	return p.writeAnnotations(left, right, oldLine.OldNumber, newLine.NewNumber)
+}
 
-	buf := &bytes.Buffer{}
-	err := genericLineTemplate.Execute(buf, struct {
-		Type          string
-		Prefix        string
-		Content       template.HTML
-		LineNumberStr string
-		LineClass     string
-		ContentClass  string
-	}{
-		Type:          lineType,
-		Prefix:        prefix,
-		Content:       template.HTML(lineWithoutPrefix),
-		LineNumberStr: lineNumberStr,
-		LineClass:     "d2h-code-side-linenumber",
-		ContentClass:  "d2h-code-side-line",
-	})
-	if err != nil {
-		return "", err
+// writeAnnotations writes the comments on a row of the diff. Each comment
+// is shown on its own side, with a placeholder keeping the other table
+// aligned.
+func (p *sideBySidePrinter) writeAnnotations(left, right io.Writer, oldNumber, newNumber int) error {
+	for _, a := range p.notes.at(oldNumber, newNumber) {
+		if err := writeAnnotationHTML(left, a, a.Side != SideOld); err != nil {
+			return err
+		}
+		if err := writeAnnotationHTML(right, a, a.Side == SideOld); err != nil {
+			return err
+		}
 	}
-	return buf.String(), nil
+	return nil
 }
 
-func (p *sideBySidePrinter) genEmptyDiff() (*fileHTML, error) {
-	fileHTML := &fileHTML{}
-	fileHTML.Right = ""
+func (p *sideBySidePrinter) genSingleLineHTML(w io.Writer, isCombined bool, lineClass string, side Side, num int, content template.HTML, prefix string) error {
+	lineNumber, err := p.links.html(side, num)
+	if err != nil {
+		return err
+	}
 
-	buf := &bytes.Buffer{}
-	err := genericEmptyDiffTemplate.Execute(buf, struct {
+	return genericLineTemplate.Execute(w, struct {
 		Type         string
+		Prefix       string
+		Content      template.HTML
+		LineNumber   template.HTML
+		LineClass    string
 		ContentClass string
 	}{
-		Type:         info,
+		Type:         lineClass,
+		Prefix:       prefix,
+		Content:      content,
+		LineNumber:   lineNumber,
+		LineClass:    "d2h-code-side-linenumber",
 		ContentClass: "d2h-code-side-line",
 	})
-	if err != nil {
-		return nil, err
-	}
-
-	fileHTML.Left = buf.String()
-	return fileHTML, nil
 }
 
-func separatePrefix(isCombined bool, line string) (string, string) {
-	prefix := ""
-	lineWithoutPrefix := ""
-
-	if isCombined {
-		prefix = line[0:2]
-		lineWithoutPrefix = line[2:]
-	} else {
-		prefix = line[0:1]
-		lineWithoutPrefix = line[1:]
-	}
-
-	return prefix, lineWithoutPrefix
-}
-
-func getHTMLID(file *File) string {
-	name := getDiffName(file)
-	hash := 0
-	for i := 0; i < len(name); i++ {
-		hash = ((hash << 5) - hash) + int(name[i])
-		hash |= 0
-	}
-	name = strconv.Itoa(hash)
-	l := int(math.Min(float64(len(name)), float64(6)))
-	return "d2h-" + name[:l]
-}
-
-func getDiffName(file *File) string {
-	oldFilename := unifyPath(file.OldName)
-	newFilename := unifyPath(file.NewName)
-
-	if oldFilename != "" && newFilename != "" && oldFilename != newFilename && !isDevNullName(oldFilename) && !isDevNullName(newFilename) {
-		return oldFilename + " → " + newFilename
-	} else if newFilename != "" && !isDevNullName(newFilename) {
-		return newFilename
-	} else if oldFilename != "" {
-		return oldFilename
-	}
-	return "unknown/file/path"
-}
-
-func unifyPath(path string) string {
-	if path != "" {
-		return strings.Replace(path, "\\", "/", 1)
-	}
-	return path
-}
-
-func isDevNullName(str string) bool {
-	return strings.HasPrefix(str, "dev/null")
-}
-
-type Highlight struct {
-	First  HighlightPart
-	Second HighlightPart
-}
-
-type HighlightPart struct {
-	Prefix string
-	Line   string
-}
-
-func diffHighlight(diffLine1, diffLine2 string, isCombined bool) Highlight {
-	prefixSize := 1
-	if isCombined {
-		prefixSize = 2
-	}
-
-	linePrefix1 := diffLine1[0:prefixSize]
-	linePrefix2 := diffLine2[0:prefixSize]
-	unprefixedLine1 := diffLine1[prefixSize:]
-	unprefixedLine2 := diffLine2[prefixSize:]
-
-	differ := diffmatchpatch.New()
-	diffs := differ.DiffMain(unprefixedLine1, unprefixedLine2, true)
-	diffs = differ.DiffCleanupSemantic(diffs)
-
-	highlightedLine := ""
-	for _, part := range diffs {
-		elemType := ""
-		if part.Type == diffmatchpatch.DiffInsert {
-			elemType = "ins"
-		} else if part.Type == diffmatchpatch.DiffDelete {
-			elemType = "del"
-		}
-		if elemType != "" {
-			highlightedLine += "<" + elemType + ">" + part.Text + "</" + elemType + ">"
-		} else {
-			highlightedLine += part.Text
-		}
-	}
-
-	return Highlight{
-		First: HighlightPart{
-			Prefix: linePrefix1,
-			Line:   removeIns(highlightedLine),
-		},
-		Second: HighlightPart{
-			Prefix: linePrefix2,
-			Line:   removeDel(highlightedLine),
-		},
-	}
-}
-
-var (
-	removeInsRegexp = regexp.MustCompile(`(<ins[^>]*>((.|\n)*?)<\/ins>)`)
-	removeDelRegexp = regexp.MustCompile(`(<del[^>]*>((.|\n)*?)<\/del>)`)
-)
-
-func removeIns(str string) string {
-	return removeInsRegexp.ReplaceAllString(str, "")
-}
-
-func removeDel(str string) string {
-	return removeDelRegexp.ReplaceAllString(str, "")
+func (p *sideBySidePrinter) genEmptyDiff(w io.Writer) error {
+	return genericEmptyDiffTemplate.Execute(w, struct {
+		Type         string
+		ContentClass string
+	}{
+		Type:         p.classes.Info,
+		ContentClass: "d2h-code-side-line",
+	})
 }
@@ -1,7 +1,8 @@
 package diff2html
 
 import (
-	"fmt"
+	"bytes"
+	"strings"
 	"testing"
 )
 
@@ -16,54 +17,251 @@ func TestNewSideBySide(t *testing.T) {
 		SrcPrefix: "",
 		DstPrefix: "",
 	})
-	d.Parser(input)
+	if err := d.Parser(input); err != nil {
+		t.Fatal(err)
+	}
 
-	side := newSideBySide()
+	side := newSideBySide(RenderConfig{})
 	html, err := side.GenerateSideBySideHTML(d.Files)
-	fmt.Println(html)
-	fmt.Println(err)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sides := strings.Split(html, `<div class="d2h-file-side-diff">`)
+	if len(sides) != 3 {
+		t.Fatalf("expected two tables:\n%s", html)
+	}
+	// The header row, the changed pair and the inserted line.
+	if left, right := strings.Count(sides[1], "<tr>"), strings.Count(sides[2], "<tr>"); left != 3 || right != 3 {
+		t.Errorf("got %d rows on the left and %d on the right, want 3 each", left, right)
+	}
+	for _, want := range []string{`<span class="d2h-code-line-ctn">test</span>`, "test<ins>1r</ins>", "test2r"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("missing %q:\n%s", want, html)
+		}
+	}
 }
 
 func TestSideBySidePrinter_makeSideHTML(t *testing.T) {
-	side := newSideBySide()
-	html, err := side.makeSideHTML("header")
-	fmt.Println(html)
-	fmt.Println(err)
+	side := newSideBySide(RenderConfig{})
+	buf := &bytes.Buffer{}
+	if err := side.makeSideHTML(buf, "@@ -1 +1 @@ <b>"); err != nil {
+		t.Fatal(err)
+	}
+	want := `<tr>
+    <td class="d2h-code-side-linenumber d2h-info"></td>
+    <td class="d2h-info">
+        <div class="d2h-code-side-line d2h-info">@@ -1 &#43;1 @@ &lt;b&gt;</div>
+    </td>
+</tr>`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
 }
 
 func TestSideBySidePrinter_genSingleLineHTML(t *testing.T) {
-	side := newSideBySide()
-	html, err := side.genSingleLineHTML(false, "d2h-cntx", 1, "{", " ")
-	fmt.Println(html)
-	fmt.Println(err)
+	side := newSideBySide(RenderConfig{})
+	buf := &bytes.Buffer{}
+	if err := side.genSingleLineHTML(buf, false, "d2h-cntx", SideOld, 1, "{", " "); err != nil {
+		t.Fatal(err)
+	}
+	want := `<tr>
+    <td class="d2h-code-side-linenumber d2h-cntx">
+        1
+    </td>
+    <td class="d2h-cntx">
+        <div class="d2h-code-side-line d2h-cntx">
+            <span class="d2h-code-line-prefix"> </span>
+            <span class="d2h-code-line-ctn">{</span>
+        </div>
+    </td>
+</tr>`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
 }
 
 func TestSideBySidePrinter_genEmptyDiff(t *testing.T) {
-	side := newSideBySide()
-	fileHTML, err := side.genEmptyDiff()
-	fmt.Println(fileHTML.Left)
-	fmt.Println(err)
+	side := newSideBySide(RenderConfig{})
+	buf := &bytes.Buffer{}
+	if err := side.genEmptyDiff(buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "File without changes") || !strings.Contains(buf.String(), `class="d2h-code-side-line d2h-info"`) {
+		t.Errorf("got\n%s", buf.String())
+	}
 }
 
 func TestSideBySidePrinter_processLines(t *testing.T) {
 	oldLine := make([]*Line, 3)
 	newLine := make([]*Line, 5)
 
-	side := newSideBySide()
-	side.processLines(true, oldLine, newLine)
+	side := newSideBySide(RenderConfig{})
+	left, right := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := side.processLines(left, right, &File{IsCombined: true}, oldLine, newLine); err != nil {
+		t.Fatal(err)
+	}
+	if left.Len() != 0 || right.Len() != 0 {
+		t.Errorf("missing lines should write nothing, got %q and %q", left.String(), right.String())
+	}
+}
+
+func TestSideBySidePrinter_processLines_annotations(t *testing.T) {
+	diff := "--- a/main.go\n" +
+		"+++ b/main.go\n" +
+		"@@ -1,5 +1,5 @@\n" +
+		" a\n" +
+		"-b\n" +
+		" c\n" +
+		"-x\n" +
+		"+y\n" +
+		"+z\n" +
+		" e\n"
+	files, err := Parse(diff, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations := []Annotation{
+		{Path: "main.go", Side: SideOld, Line: 2, Body: "deleted"},
+		{Path: "main.go", Side: SideNew, Line: 4, Body: "inserted"},
+	}
+
+	html, err := Render(files, RenderConfig{Annotations: annotations})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sides := strings.Split(html, `<div class="d2h-file-side-diff">`)
+	if len(sides) != 3 {
+		t.Fatalf("expected two tables, got %d", len(sides)-1)
+	}
+	for i, side := range sides[1:] {
+		if got := strings.Count(side, `<tr class="d2h-annotation`); got != 2 {
+			t.Errorf("table %d: expected 2 comment rows, got %d", i, got)
+		}
+		if got := strings.Count(side, "d2h-annotation-placeholder"); got != 1 {
+			t.Errorf("table %d: expected 1 placeholder, got %d", i, got)
+		}
+	}
 }
 
 func Test_getDiffName(t *testing.T) {
-	file := &File{
-		OldName: "sample",
-		NewName: "sample2",
+	tests := []struct {
+		file *File
+		want string
+	}{
+		{&File{OldName: "sample", NewName: "sample2"}, "sample → sample2"},
+		{&File{OldName: "sample", NewName: "sample"}, "sample"},
+		{&File{OldName: "/dev/null", NewName: "new"}, "new"},
+		{&File{OldName: "old", NewName: "/dev/null"}, "old"},
+		{&File{}, "unknown/file/path"},
+	}
+	for _, tt := range tests {
+		if got := getDiffName(tt.file); got != tt.want {
+			t.Errorf("getDiffName(%q, %q) = %q, want %q", tt.file.OldName, tt.file.NewName, got, tt.want)
+		}
+	}
+}
+
+func Test_isDevNullName(t *testing.T) {
+	tests := map[string]bool{
+		"/dev/null":           true,
+		"dev/null":            true,
+		"src/dev/nullable.go": false,
+		"dev/null.go":         false,
+		"":                    false,
+	}
+	for name, want := range tests {
+		if got := isDevNullName(name); got != want {
+			t.Errorf("isDevNullName(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	files, err := Parse("--- a/src/dev/nullable.go\n+++ b/src/dev/nullable.go\n@@ -1 +1 @@\n-a\n+b\n", Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fileStatus(files[0]); got != statusModified {
+		t.Errorf("status of src/dev/nullable.go: got %q", got)
+	}
+}
+
+func Test_getHTMLID(t *testing.T) {
+	tests := []struct {
+		file *File
+		want string
+	}{
+		{&File{OldName: "src/main.go", NewName: "src/main.go"}, "d2h-src-main-go"},
+		{&File{OldName: "src/old.go", NewName: "src/main.go"}, "d2h-src-main-go"},
+		{&File{OldName: "/dev/null", NewName: "Docs/READ ME.md"}, "d2h-docs-read-me-md"},
+		{&File{OldName: "src\\lib.rs", NewName: "/dev/null"}, "d2h-src-lib-rs"},
+		{&File{OldName: ".gitignore", NewName: ".gitignore"}, "d2h-gitignore"},
+		{&File{OldName: "日本", NewName: "日本"}, "d2h-file"},
+	}
+	for _, tt := range tests {
+		if got := getHTMLID(tt.file); got != tt.want {
+			t.Errorf("getHTMLID(%s) = %s, want %s", tt.file.NewName, got, tt.want)
+		}
+	}
+}
+
+func Test_fileAnchors(t *testing.T) {
+	files := []*File{
+		{OldName: "main.go", NewName: "main.go"},
+		{OldName: "main_go", NewName: "main_go"},
+		{OldName: "main-go-2", NewName: "main-go-2"},
+		{OldName: "main.go", NewName: "main.go"},
+	}
+	anchors := fileAnchors(files)
+	want := []string{"d2h-main-go", "d2h-main-go-2", "d2h-main-go-2-2", "d2h-main-go-3"}
+	for i, file := range files {
+		if anchors[file] != want[i] {
+			t.Errorf("file %d: got %s, want %s", i, anchors[file], want[i])
+		}
 	}
-	name := getDiffName(file)
-	fmt.Println(name)
 }
 
 func Test_diffHighlight(t *testing.T) {
-	highlight := diffHighlight(" category:campaign,", " category:guidance,", false)
-	fmt.Println(highlight.First.Line)
-	fmt.Println(highlight.Second.Line)
+	highlight := diffHighlight(" category:campaign,", " category:guidance,", false, DiffStyleWord, IgnoreWhitespaceNone, nil, "")
+	if highlight.First.Prefix != " " || highlight.Second.Prefix != " " {
+		t.Errorf("prefixes: got %q, %q", highlight.First.Prefix, highlight.Second.Prefix)
+	}
+	if got := string(highlight.First.Line); got != "category:<del>campaign</del>," {
+		t.Errorf("first: got %s", got)
+	}
+	if got := string(highlight.Second.Line); got != "category:<ins>guidance</ins>," {
+		t.Errorf("second: got %s", got)
+	}
+}
+
+func TestSideBySidePrinter_matching(t *testing.T) {
+	input := "--- a/sample.go\n" +
+		"+++ b/sample.go\n" +
+		"@@ -1,2 +1,3 @@\n" +
+		"-func sum(a, b int) int {\n" +
+		"-\treturn a + b\n" +
+		"+// sum adds two numbers.\n" +
+		"+func sum(a, b, c int) int {\n" +
+		"+\treturn a + b + c\n"
+	d := newDiff(Config{})
+	if err := d.Parser(input); err != nil {
+		t.Fatal(err)
+	}
+
+	html, err := newSideBySide(RenderConfig{}).GenerateSideBySideHTML(d.Files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html, "<ins>//") {
+		t.Errorf("positional pairing should highlight the comment against the old signature")
+	}
+
+	html, err = newSideBySide(RenderConfig{Matching: MatchingLines}).GenerateSideBySideHTML(d.Files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(html, "<ins>//") {
+		t.Errorf("the new comment should not be paired:\n%s", html)
+	}
+	if !strings.Contains(html, "<ins>") {
+		t.Errorf("matched lines should be word-highlighted:\n%s", html)
+	}
 }
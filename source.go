@@ -0,0 +1,276 @@
+package diff2html
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// FullContext, as the number of lines to expand, fills every gap so the
+// whole file is shown.
+const FullContext = -1
+
+// ErrSourceMismatch is returned, wrapped, by ExpandContext when the source
+// of a file does not agree with the diff.
+var ErrSourceMismatch = errors.New("diff2html: source does not match the diff")
+
+// SourceProvider gives access to the contents of a file before and after
+// the change, so that context beyond the hunks can be shown. Either
+// content may be nil when it is not available.
+type SourceProvider interface {
+	Source(file *File) (oldContent, newContent []byte, err error)
+}
+
+// SourceFunc adapts a function to a SourceProvider.
+type SourceFunc func(file *File) (oldContent, newContent []byte, err error)
+
+// Source implements SourceProvider.
+func (f SourceFunc) Source(file *File) ([]byte, []byte, error) {
+	return f(file)
+}
+
+// FSSource returns a SourceProvider reading the old version of a file
+// from oldFS and the new version from newFS, by the names in the diff.
+// Either file system may be nil, and missing files are not an error.
+func FSSource(oldFS, newFS fs.FS) SourceProvider {
+	return SourceFunc(func(file *File) ([]byte, []byte, error) {
+		oldContent, err := readSource(oldFS, file.OldName)
+		if err != nil {
+			return nil, nil, err
+		}
+		newContent, err := readSource(newFS, file.NewName)
+		if err != nil {
+			return nil, nil, err
+		}
+		return oldContent, newContent, nil
+	})
+}
+
+func readSource(fsys fs.FS, name string) ([]byte, error) {
+	if fsys == nil || name == "" || isDevNullName(name) {
+		return nil, nil
+	}
+	content, err := fs.ReadFile(fsys, path.Clean(strings.TrimPrefix(unifyPath(name), "/")))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return content, err
+}
+
+// ExpandContext adds up to lines unchanged lines from src before and
+// after every block of file, merging blocks whose gap is filled. With
+// FullContext the whole file is shown. Combined diffs and files without
+// source are left as they are.
+func ExpandContext(file *File, src SourceProvider, lines int) error {
+	if lines == 0 || file.IsCombined || len(file.Blocks) == 0 {
+		return nil
+	}
+	oldContent, newContent, err := src.Source(file)
+	if err != nil {
+		return err
+	}
+
+	// Unchanged lines are the same in both versions; read them from the
+	// new one when there is a choice.
+	content, useNew := newContent, true
+	if content == nil {
+		content, useNew = oldContent, false
+	}
+	if content == nil {
+		return nil
+	}
+	source := splitSource(content)
+
+	spans := make([]blockSpan, len(file.Blocks))
+	for i, block := range file.Blocks {
+		spans[i] = newBlockSpan(block, useNew)
+		if err := spans[i].check(block, source, useNew); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrSourceMismatch, getDiffName(file), err)
+		}
+	}
+
+	blocks := []*Block{}
+	grown := map[*Block]bool{}
+	var current *Block
+	for i, span := range spans {
+		block := &Block{
+			Lines:        append([]*Line{}, file.Blocks[i].Lines...),
+			OldStartLine: file.Blocks[i].OldStartLine,
+			NewStartLine: file.Blocks[i].NewStartLine,
+			Header:       file.Blocks[i].Header,
+		}
+
+		// The gap before this block, in line numbers of the source.
+		gapStart := 1
+		if i > 0 {
+			gapStart = spans[i-1].last + 1
+		}
+		gapEnd := span.first - 1
+		gap := gapEnd - gapStart + 1
+
+		if current != nil && (lines < 0 || 2*lines >= gap) {
+			current.Lines = append(current.Lines, contextLines(source, gapStart, gapEnd, span.offset, useNew)...)
+			current.Lines = append(current.Lines, block.Lines...)
+			grown[current] = true
+			continue
+		}
+		if current != nil {
+			current.Lines = append(current.Lines, contextLines(source, gapStart, gapStart+lines-1, spans[i-1].after, useNew)...)
+			grown[current] = true
+			blocks = append(blocks, current)
+		}
+		if lines >= 0 && gap > lines {
+			gapStart = gapEnd - lines + 1
+		}
+		if gapStart <= gapEnd {
+			block.Lines = append(contextLines(source, gapStart, gapEnd, span.offset, useNew), block.Lines...)
+			grown[block] = true
+		}
+		current = block
+	}
+
+	last := spans[len(spans)-1]
+	end := len(source)
+	if lines >= 0 && end > last.last+lines {
+		end = last.last + lines
+	}
+	if last.last < end {
+		current.Lines = append(current.Lines, contextLines(source, last.last+1, end, last.after, useNew)...)
+		grown[current] = true
+	}
+	blocks = append(blocks, current)
+
+	for _, block := range blocks {
+		if grown[block] {
+			updateBlockHeader(block)
+		}
+	}
+	file.Blocks = blocks
+	return nil
+}
+
+// blockSpan is the range of source lines a block covers, and the
+// difference between the line numbers of the other version and the
+// source above and below it.
+type blockSpan struct {
+	first, last   int
+	offset, after int
+}
+
+func newBlockSpan(block *Block, useNew bool) blockSpan {
+	oldCount, newCount := 0, 0
+	for _, line := range block.Lines {
+		if line.Type != LineInsert {
+			oldCount++
+		}
+		if line.Type != LineDelete {
+			newCount++
+		}
+	}
+	// An empty side of a hunk starts at the line before its position.
+	oldFirst := block.OldStartLine
+	if oldCount == 0 {
+		oldFirst++
+	}
+	newFirst := block.NewStartLine
+	if newCount == 0 {
+		newFirst++
+	}
+	if useNew {
+		return blockSpan{
+			first:  newFirst,
+			last:   newFirst + newCount - 1,
+			offset: oldFirst - newFirst,
+			after:  oldFirst + oldCount - newFirst - newCount,
+		}
+	}
+	return blockSpan{
+		first:  oldFirst,
+		last:   oldFirst + oldCount - 1,
+		offset: newFirst - oldFirst,
+		after:  newFirst + newCount - oldFirst - oldCount,
+	}
+}
+
+// check reports whether the lines of block agree with the source.
+func (s blockSpan) check(block *Block, source []readLine, useNew bool) error {
+	if s.last > len(source) {
+		return fmt.Errorf("source has %d lines, the diff needs %d", len(source), s.last)
+	}
+	for _, line := range block.Lines {
+		number := line.OldNumber
+		if useNew {
+			number = line.NewNumber
+		}
+		if number == 0 || (useNew && line.Type == LineDelete) || (!useNew && line.Type == LineInsert) {
+			continue
+		}
+		if len(line.Content) < 1 || source[number-1].text != line.Content[1:] {
+			return fmt.Errorf("line %d differs", number)
+		}
+	}
+	return nil
+}
+
+// contextLines returns source lines from to to, inclusive, as context.
+func contextLines(source []readLine, from, to, offset int, useNew bool) []*Line {
+	lines := []*Line{}
+	for n := from; n <= to; n++ {
+		line := &Line{Content: " " + source[n-1].text, CRLF: source[n-1].crlf, Type: LineContext, OldNumber: n + offset, NewNumber: n}
+		if !useNew {
+			line.OldNumber, line.NewNumber = n, n+offset
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// updateBlockHeader renumbers a block after lines were added to it,
+// keeping the section heading git put after the line ranges.
+func updateBlockHeader(block *Block) {
+	oldCount, newCount := 0, 0
+	oldStart, newStart := 0, 0
+	for _, line := range block.Lines {
+		if line.Type != LineInsert {
+			oldCount++
+			if oldStart == 0 {
+				oldStart = line.OldNumber
+			}
+		}
+		if line.Type != LineDelete {
+			newCount++
+			if newStart == 0 {
+				newStart = line.NewNumber
+			}
+		}
+	}
+	if oldStart == 0 {
+		oldStart = block.OldStartLine
+	}
+	if newStart == 0 {
+		newStart = block.NewStartLine
+	}
+	block.OldStartLine, block.NewStartLine = oldStart, newStart
+
+	heading := ""
+	if i := strings.Index(block.Header, "@@"); i >= 0 {
+		if j := strings.Index(block.Header[i+2:], "@@"); j >= 0 {
+			heading = block.Header[i+2+j+2:]
+		}
+	}
+	block.Header = fmt.Sprintf("@@ -%d,%d +%d,%d @@%s", oldStart, oldCount, newStart, newCount, heading)
+}
+
+// splitSource splits file contents into lines the way the parser reads
+// the lines of a diff, so that they compare equal to them.
+func splitSource(content []byte) []readLine {
+	lines := []readLine{}
+	lr := newLineReader(bytes.NewReader(content), false)
+	for lr.next() {
+		lines = append(lines, readLine{text: lr.line(), crlf: lr.crlf()})
+	}
+	return lines
+}
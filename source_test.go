@@ -0,0 +1,238 @@
+package diff2html
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// numbered returns the lines "1" to "n", one per line.
+func numbered(n int) string {
+	buf := &strings.Builder{}
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(buf, "%d\n", i)
+	}
+	return buf.String()
+}
+
+// twoHunks changes line 5 and line 15 of a 20 line file.
+const twoHunks = "--- a/num.txt\n" +
+	"+++ b/num.txt\n" +
+	"@@ -4,3 +4,3 @@ first\n" +
+	" 4\n" +
+	"-5\n" +
+	"+five\n" +
+	" 6\n" +
+	"@@ -14,3 +14,3 @@ second\n" +
+	" 14\n" +
+	"-15\n" +
+	"+fifteen\n" +
+	" 16\n"
+
+func newSource() SourceProvider {
+	newContent := strings.Replace(strings.Replace(numbered(20), "\n5\n", "\nfive\n", 1), "\n15\n", "\nfifteen\n", 1)
+	return FSSource(
+		fstest.MapFS{"num.txt": {Data: []byte(numbered(20))}},
+		fstest.MapFS{"num.txt": {Data: []byte(newContent)}},
+	)
+}
+
+func blockNumbers(block *Block) string {
+	numbers := []string{}
+	for _, line := range block.Lines {
+		numbers = append(numbers, fmt.Sprintf("%d/%d", line.OldNumber, line.NewNumber))
+	}
+	return strings.Join(numbers, " ")
+}
+
+func TestExpandContext(t *testing.T) {
+	tests := []struct {
+		lines   int
+		headers []string
+		first   string
+	}{
+		{0, []string{"@@ -4,3 +4,3 @@ first", "@@ -14,3 +14,3 @@ second"}, "4/4 5/0 0/5 6/6"},
+		{2, []string{"@@ -2,7 +2,7 @@ first", "@@ -12,7 +12,7 @@ second"}, "2/2 3/3 4/4 5/0 0/5 6/6 7/7 8/8"},
+		{4, []string{"@@ -1,20 +1,20 @@ first"}, ""},
+		{FullContext, []string{"@@ -1,20 +1,20 @@ first"}, ""},
+	}
+	for _, tt := range tests {
+		files, err := Parse(twoHunks, Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		file := files[0]
+		if err := ExpandContext(file, newSource(), tt.lines); err != nil {
+			t.Fatal(err)
+		}
+
+		headers := []string{}
+		for _, block := range file.Blocks {
+			headers = append(headers, block.Header)
+		}
+		if strings.Join(headers, "|") != strings.Join(tt.headers, "|") {
+			t.Errorf("%d lines: got headers %q, want %q", tt.lines, headers, tt.headers)
+		}
+		if tt.first != "" && blockNumbers(file.Blocks[0]) != tt.first {
+			t.Errorf("%d lines: got %s, want %s", tt.lines, blockNumbers(file.Blocks[0]), tt.first)
+		}
+		if len(file.Blocks) == 1 && len(file.Blocks[0].Lines) != 22 {
+			t.Errorf("%d lines: got %d lines, want 22", tt.lines, len(file.Blocks[0].Lines))
+		}
+	}
+}
+
+func TestExpandContext_insertedLine(t *testing.T) {
+	// Lines are inserted after line 2 and line 8.
+	diff := "--- a/num.txt\n" +
+		"+++ b/num.txt\n" +
+		"@@ -1,3 +1,4 @@\n" +
+		" 1\n" +
+		" 2\n" +
+		"+new\n" +
+		" 3\n" +
+		"@@ -7,3 +8,4 @@\n" +
+		" 7\n" +
+		" 8\n" +
+		"+more\n" +
+		" 9\n"
+	newContent := strings.Replace(strings.Replace(numbered(10), "\n2\n", "\n2\nnew\n", 1), "\n8\n", "\n8\nmore\n", 1)
+	src := FSSource(
+		fstest.MapFS{"num.txt": {Data: []byte(numbered(10))}},
+		fstest.MapFS{"num.txt": {Data: []byte(newContent)}},
+	)
+
+	tests := []struct {
+		lines int
+		want  []string
+	}{
+		{1, []string{"1/1 2/2 0/3 3/4 4/5", "6/7 7/8 8/9 0/10 9/11 10/12"}},
+		{FullContext, []string{"1/1 2/2 0/3 3/4 4/5 5/6 6/7 7/8 8/9 0/10 9/11 10/12"}},
+	}
+	for _, tt := range tests {
+		files, err := Parse(diff, Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ExpandContext(files[0], src, tt.lines); err != nil {
+			t.Fatal(err)
+		}
+		got := []string{}
+		for _, block := range files[0].Blocks {
+			got = append(got, blockNumbers(block))
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%d lines: got %q, want %q", tt.lines, got, tt.want)
+		}
+	}
+}
+
+func TestExpandContext_oldSource(t *testing.T) {
+	files, err := Parse(twoHunks, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := FSSource(fstest.MapFS{"num.txt": {Data: []byte(numbered(20))}}, nil)
+	if err := ExpandContext(files[0], src, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := blockNumbers(files[0].Blocks[1]); got != "13/13 14/14 15/0 0/15 16/16 17/17" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestExpandContext_errors(t *testing.T) {
+	files, err := Parse(twoHunks, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stale := FSSource(nil, fstest.MapFS{"num.txt": {Data: []byte(numbered(20))}})
+	if err := ExpandContext(files[0], stale, 1); !errors.Is(err, ErrSourceMismatch) {
+		t.Errorf("got %v, want a mismatch error", err)
+	}
+
+	short := FSSource(nil, fstest.MapFS{"num.txt": {Data: []byte(numbered(10))}})
+	if err := ExpandContext(files[0], short, 1); !errors.Is(err, ErrSourceMismatch) {
+		t.Errorf("got %v, want a mismatch error for a short source", err)
+	}
+
+	failing := SourceFunc(func(file *File) ([]byte, []byte, error) {
+		return nil, nil, errors.New("boom")
+	})
+	if err := ExpandContext(files[0], failing, 1); err == nil || err.Error() != "boom" {
+		t.Errorf("got %v", err)
+	}
+
+	if err := ExpandContext(files[0], FSSource(nil, fstest.MapFS{}), 1); err != nil {
+		t.Errorf("missing files should be skipped, got %v", err)
+	}
+	if len(files[0].Blocks) != 2 {
+		t.Error("failed expansions should leave the file as it is")
+	}
+}
+
+func TestExpandContext_carriageReturns(t *testing.T) {
+	// The last line ends with a carriage return but no newline.
+	diff := "--- a/crlf.txt\n" +
+		"+++ b/crlf.txt\n" +
+		"@@ -3 +3 @@\n" +
+		"-c\r\n" +
+		"\\ No newline at end of file\n" +
+		"+d\r\n" +
+		"\\ No newline at end of file\n"
+	src := FSSource(nil, fstest.MapFS{"crlf.txt": {Data: []byte("a\r\nb\r\nd\r")}})
+
+	files, err := Parse(diff, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ExpandContext(files[0], src, FullContext); err != nil {
+		t.Fatal(err)
+	}
+	lines := files[0].Blocks[0].Lines
+	if len(lines) != 4 || lines[0].Content != " a" || !lines[0].CRLF || lines[3].Content != "+d\r" {
+		t.Errorf("got %+v", lines)
+	}
+}
+
+func TestRender_expandContext(t *testing.T) {
+	files, err := Parse(twoHunks, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	html, err := Render(files, RenderConfig{Source: newSource(), ExpandContext: FullContext, OutputFormat: LineByLine})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html, `id="d2h-num-txt-L20"`) {
+		t.Error("expected the last line of the file")
+	}
+	if len(files[0].Blocks) != 2 {
+		t.Error("rendering should not modify the files")
+	}
+}
+
+func TestRender_expandContextMismatch(t *testing.T) {
+	files, err := Parse(twoHunks+"--- a/other.txt\n+++ b/other.txt\n@@ -1 +1 @@\n-1\n+one\n", Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The source of num.txt is out of date, other.txt matches.
+	src := FSSource(nil, fstest.MapFS{
+		"num.txt":   {Data: []byte(numbered(20))},
+		"other.txt": {Data: []byte("one\n2\n")},
+	})
+	html, err := Render(files, RenderConfig{Source: src, ExpandContext: FullContext, OutputFormat: LineByLine})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(html, `id="d2h-num-txt-L20"`) {
+		t.Error("expected num.txt without expansion")
+	}
+	if !strings.Contains(html, `id="d2h-other-txt-R2"`) {
+		t.Error("expected other.txt to be expanded")
+	}
+}
@@ -0,0 +1,195 @@
+package diff2html
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+)
+
+// diffstatWidth is the number of boxes in a diffstat bar.
+const diffstatWidth = 5
+
+var (
+	summaryTemplate = template.Must(template.New("summary").Parse(summary))
+)
+
+// FileStats counts the changes of one file.
+type FileStats struct {
+	File    *File
+	Name    string
+	Added   int
+	Deleted int
+	Blocks  int
+}
+
+// Changed is the number of added and deleted lines.
+func (s FileStats) Changed() int {
+	return s.Added + s.Deleted
+}
+
+// Stats summarizes the changes of a whole diff.
+type Stats struct {
+	// Files holds the stats of every file, in diff order.
+	Files   []FileStats
+	Added   int
+	Deleted int
+
+	NewFiles     int
+	DeletedFiles int
+	Renamed      int
+	Copied       int
+	Binary       int
+}
+
+// ComputeStats counts the changes of files.
+func ComputeStats(files []*File) *Stats {
+	s := &Stats{Files: make([]FileStats, 0, len(files))}
+	for _, file := range files {
+		fs := newFileStats(file)
+		s.Files = append(s.Files, fs)
+		s.Added += fs.Added
+		s.Deleted += fs.Deleted
+
+		switch fileStatus(file) {
+		case statusAdded:
+			s.NewFiles++
+		case statusDeleted:
+			s.DeletedFiles++
+		case statusRenamed:
+			s.Renamed++
+		case statusCopied:
+			s.Copied++
+		}
+		if file.IsBinary {
+			s.Binary++
+		}
+	}
+	return s
+}
+
+func newFileStats(file *File) FileStats {
+	return FileStats{
+		File:    file,
+		Name:    getDiffName(file),
+		Added:   file.AddedLines,
+		Deleted: file.DeletedLines,
+		Blocks:  len(file.Blocks),
+	}
+}
+
+// Changed is the total of added and deleted lines across all files of
+// the diff.
+func (s *Stats) Changed() int {
+	return s.Added + s.Deleted
+}
+
+// Largest returns up to n files with the most changed lines, largest
+// first. Files with as many changes keep their diff order.
+func (s *Stats) Largest(n int) []FileStats {
+	files := append([]FileStats{}, s.Files...)
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Changed() > files[j].Changed()
+	})
+	if n < len(files) {
+		files = files[:n]
+	}
+	return files
+}
+
+// diffstatBar splits a bar of diffstatWidth boxes into "added", "deleted"
+// and "neutral" boxes. The filled part is scaled to the file with the
+// most changes, max, and is at least one box for any change.
+func diffstatBar(added, deleted, max int) []string {
+	filled := 0
+	if changed := added + deleted; changed > 0 && max > 0 {
+		filled = (changed*diffstatWidth + max - 1) / max
+		if filled > diffstatWidth {
+			filled = diffstatWidth
+		}
+	}
+	addedBoxes := 0
+	if filled > 0 {
+		addedBoxes = (added*filled*2 + added + deleted) / ((added + deleted) * 2)
+		// Keep both colors visible when there is room for them.
+		if added > 0 && addedBoxes == 0 {
+			addedBoxes = 1
+		}
+		if deleted > 0 && addedBoxes == filled && filled > 1 {
+			addedBoxes--
+		}
+	}
+
+	bar := make([]string, 0, diffstatWidth)
+	for i := 0; i < diffstatWidth; i++ {
+		switch {
+		case i < addedBoxes:
+			bar = append(bar, "added")
+		case i < filled:
+			bar = append(bar, "deleted")
+		default:
+			bar = append(bar, "neutral")
+		}
+	}
+	return bar
+}
+
+// summaryTitle describes stats the way git diff --stat does.
+func summaryTitle(stats *Stats) string {
+	plural := func(n int, one, many string) string {
+		if n == 1 {
+			return fmt.Sprintf("%d %s", n, one)
+		}
+		return fmt.Sprintf("%d %s", n, many)
+	}
+	return plural(len(stats.Files), "file changed", "files changed") + ", " +
+		plural(stats.Added, "insertion(+)", "insertions(+)") + ", " +
+		plural(stats.Deleted, "deletion(-)", "deletions(-)")
+}
+
+// writeSummaryHTML writes the list of files with their diffstat, linking
+// to each file by its anchor.
+func writeSummaryHTML(w io.Writer, files []*File, anchors map[*File]string) error {
+	stats := ComputeStats(files)
+	max := 0
+	for _, fs := range stats.Files {
+		if fs.Changed() > max {
+			max = fs.Changed()
+		}
+	}
+
+	iconHTML, err := makeIconHTML()
+	if err != nil {
+		return err
+	}
+
+	type row struct {
+		ID      string
+		Name    string
+		Added   int
+		Deleted int
+		Binary  bool
+		Bar     []string
+	}
+	rows := make([]row, 0, len(stats.Files))
+	for _, fs := range stats.Files {
+		rows = append(rows, row{
+			ID:      anchors[fs.File],
+			Name:    fs.Name,
+			Added:   fs.Added,
+			Deleted: fs.Deleted,
+			Binary:  fs.File.IsBinary,
+			Bar:     diffstatBar(fs.Added, fs.Deleted, max),
+		})
+	}
+
+	return summaryTemplate.Execute(w, struct {
+		Title    string
+		FileIcon template.HTML
+		Rows     []row
+	}{
+		Title:    summaryTitle(stats),
+		FileIcon: template.HTML(iconHTML),
+		Rows:     rows,
+	})
+}
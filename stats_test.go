@@ -0,0 +1,136 @@
+package diff2html
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const statsInput = "diff --git a/small.go b/small.go\n" +
+	"--- a/small.go\n" +
+	"+++ b/small.go\n" +
+	"@@ -1 +1 @@\n" +
+	"-a\n" +
+	"+b\n" +
+	"diff --git a/big.go b/big.go\n" +
+	"new file mode 100644\n" +
+	"--- /dev/null\n" +
+	"+++ b/big.go\n" +
+	"@@ -0,0 +1,4 @@\n" +
+	"+1\n" +
+	"+2\n" +
+	"+3\n" +
+	"+4\n" +
+	"diff --git a/old.txt b/new.txt\n" +
+	"similarity index 100%\n" +
+	"rename from old.txt\n" +
+	"rename to new.txt\n" +
+	"diff --git a/logo.png b/logo.png\n" +
+	"index 1234567..89abcde 100644\n" +
+	"Binary files a/logo.png and b/logo.png differ\n"
+
+func TestComputeStats(t *testing.T) {
+	files, err := Parse(statsInput, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := ComputeStats(files)
+
+	if stats.Added != 5 || stats.Deleted != 1 || stats.Changed() != 6 || len(stats.Files) != 4 {
+		t.Errorf("got %+v", stats)
+	}
+	if stats.NewFiles != 1 || stats.DeletedFiles != 0 || stats.Renamed != 1 || stats.Copied != 0 || stats.Binary != 1 {
+		t.Errorf("got %+v", stats)
+	}
+	if fs := stats.Files[2]; fs.Name != "old.txt → new.txt" || fs.Changed() != 0 || fs.File != files[2] {
+		t.Errorf("got %+v", fs)
+	}
+
+	names := []string{}
+	for _, fs := range stats.Largest(3) {
+		names = append(names, fs.Name)
+	}
+	if want := []string{"big.go", "small.go", "old.txt → new.txt"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Largest(3) = %v, want %v", names, want)
+	}
+	if n := len(stats.Largest(10)); n != 4 {
+		t.Errorf("Largest(10) returned %d files", n)
+	}
+}
+
+func Test_diffstatBar(t *testing.T) {
+	tests := []struct {
+		added, deleted, max int
+		want                string
+	}{
+		{0, 0, 10, "nnnnn"},
+		{10, 0, 10, "aaaaa"},
+		{5, 5, 10, "aaadd"},
+		{1, 0, 100, "annnn"},
+		{9, 1, 10, "aaaad"},
+		{1, 9, 10, "adddd"},
+		{2, 2, 8, "aadnn"},
+		{2, 2, 40, "annnn"},
+	}
+	for _, tt := range tests {
+		got := ""
+		for _, box := range diffstatBar(tt.added, tt.deleted, tt.max) {
+			got += box[:1]
+		}
+		if got != tt.want {
+			t.Errorf("diffstatBar(%d, %d, %d) = %s, want %s", tt.added, tt.deleted, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestRender_summary(t *testing.T) {
+	files, err := Parse(statsInput, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	html, err := Render(files, RenderConfig{Summary: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"4 files changed, 5 insertions(&#43;), 1 deletion(-)",
+		`<a href="#d2h-big-go" class="d2h-file-name">big.go</a>`,
+		`<span class="d2h-lines-added">+4</span>`,
+		`<span class="d2h-lines-binary">binary</span>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+	if strings.Index(html, "d2h-file-list-wrapper") > strings.Index(html, "d2h-file-wrapper") {
+		t.Error("the summary should come before the files")
+	}
+
+	html, err = Render(files, RenderConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(html, "d2h-file-list-wrapper") {
+		t.Error("unexpected summary")
+	}
+}
+
+func TestRender_summaryAnchors(t *testing.T) {
+	input := "--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-a\n+b\n" +
+		"--- a/main.go\n+++ b/main.go\n@@ -5 +5 @@\n-c\n+d\n"
+	files, err := Parse(input, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		html, err := Render(files, RenderConfig{Summary: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, id := range []string{"d2h-main-go", "d2h-main-go-2"} {
+			if !strings.Contains(html, `href="#`+id+`"`) || !strings.Contains(html, `id="`+id+`"`) {
+				t.Errorf("render %d: missing link or anchor %s", i, id)
+			}
+		}
+	}
+}
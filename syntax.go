@@ -0,0 +1,147 @@
+package diff2html
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SyntaxHighlighter splits the content of a line into tokens for syntax
+// highlighting. Lines are highlighted one at a time, so constructs that
+// span several lines, like block comments, are only partly recognized.
+type SyntaxHighlighter interface {
+	// Highlight splits line, written in language, into tokens. The texts
+	// of the tokens must add up to line. language is File.Language.
+	Highlight(language, line string) []SyntaxToken
+}
+
+// SyntaxToken is a piece of a line with the CSS class it is shown with.
+type SyntaxToken struct {
+	Text string
+	// Class is empty for text that is not highlighted.
+	Class string
+}
+
+// CSS classes of the tokens returned by BasicHighlighter.
+const (
+	SyntaxKeyword = "d2h-syntax-keyword"
+	SyntaxString  = "d2h-syntax-string"
+	SyntaxComment = "d2h-syntax-comment"
+	SyntaxNumber  = "d2h-syntax-number"
+)
+
+// BasicHighlighter is a small SyntaxHighlighter that recognizes keywords,
+// strings, comments and numbers of common languages. Lines of other
+// languages are returned as a single plain token.
+type BasicHighlighter struct{}
+
+type basicLanguage struct {
+	pattern  *regexp.Regexp
+	keywords map[string]bool
+}
+
+const (
+	cComments      = `//.*|/\*.*?(?:\*/|$)`
+	hashComments   = `#.*`
+	doubleQuoted   = `"(?:[^"\\]|\\.)*"?`
+	singleQuoted   = `'(?:[^'\\]|\\.)*'?`
+	backtickQuoted = "`[^`]*`?"
+)
+
+var (
+	basicCLike = newBasicLanguage(cComments, doubleQuoted+"|"+singleQuoted,
+		"auto break case char const continue default do double else enum extern float for goto if inline int long register return short signed sizeof static struct switch typedef union unsigned void volatile while "+
+			"bool class delete false friend namespace new nullptr operator private protected public template this throw true try catch using virtual")
+	basicLanguages = map[string]*basicLanguage{
+		"go": newBasicLanguage(cComments, doubleQuoted+"|"+singleQuoted+"|"+backtickQuoted,
+			"break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var "+
+				"true false nil iota"),
+		"javascript": newBasicLanguage(cComments, doubleQuoted+"|"+singleQuoted+"|"+backtickQuoted,
+			"async await break case catch class const continue debugger default delete do else export extends false finally for from function if import in instanceof let new null of return super switch this throw true try typeof undefined var void while with yield"),
+		"typescript": newBasicLanguage(cComments, doubleQuoted+"|"+singleQuoted+"|"+backtickQuoted,
+			"abstract any as async await boolean break case catch class const continue declare default delete do else enum export extends false finally for from function if implements import in instanceof interface let new null number of private protected public readonly return string super switch this throw true try type typeof undefined var void while yield"),
+		"python": newBasicLanguage(hashComments, doubleQuoted+"|"+singleQuoted,
+			"and as assert async await break class continue def del elif else except False finally for from global if import in is lambda None nonlocal not or pass raise return True try while with yield"),
+		"ruby": newBasicLanguage(hashComments, doubleQuoted+"|"+singleQuoted,
+			"alias and begin break case class def defined do else elsif end ensure false for if in module next nil not or redo rescue retry return self super then true undef unless until when while yield"),
+		"java": newBasicLanguage(cComments, doubleQuoted+"|"+singleQuoted,
+			"abstract assert boolean break byte case catch char class const continue default do double else enum extends false final finally float for if implements import instanceof int interface long native new null package private protected public return short static super switch synchronized this throw throws transient true try void volatile while"),
+		"c":   basicCLike,
+		"cpp": basicCLike,
+		"csharp": newBasicLanguage(cComments, doubleQuoted+"|"+singleQuoted,
+			"abstract as base bool break case catch class const continue default delegate do else enum event false finally for foreach if in int interface internal is namespace new null object out override private protected public readonly ref return sealed static string struct switch this throw true try using var virtual void while"),
+		"rust": newBasicLanguage(cComments, doubleQuoted,
+			"as async await break const continue crate else enum extern false fn for if impl in let loop match mod move mut pub ref return self Self static struct super trait true type unsafe use where while"),
+		"php": newBasicLanguage(cComments+"|"+hashComments, doubleQuoted+"|"+singleQuoted,
+			"abstract array as break case catch class const continue default do echo else elseif extends false final finally for foreach function if implements interface namespace new null private protected public return static switch throw true try use while"),
+		"shell": newBasicLanguage(hashComments, doubleQuoted+"|"+singleQuoted,
+			"case do done elif else esac exit export fi for function if in local return then until while"),
+	}
+)
+
+func newBasicLanguage(comments, strs, keywords string) *basicLanguage {
+	l := &basicLanguage{
+		pattern:  regexp.MustCompile(`(` + comments + `)|(` + strs + `)|(\b\d[\w.]*)|([\p{L}_$][\p{L}\p{N}_$]*)`),
+		keywords: map[string]bool{},
+	}
+	for _, keyword := range strings.Fields(keywords) {
+		l.keywords[keyword] = true
+	}
+	return l
+}
+
+// Highlight implements SyntaxHighlighter.
+func (BasicHighlighter) Highlight(language, line string) []SyntaxToken {
+	l, ok := basicLanguages[language]
+	if !ok {
+		return []SyntaxToken{{Text: line}}
+	}
+
+	tokens := []SyntaxToken{}
+	plain := 0
+	for _, m := range l.pattern.FindAllStringSubmatchIndex(line, -1) {
+		class := ""
+		switch {
+		case m[2] >= 0:
+			class = SyntaxComment
+		case m[4] >= 0:
+			class = SyntaxString
+		case m[6] >= 0:
+			class = SyntaxNumber
+		case l.keywords[line[m[8]:m[9]]]:
+			class = SyntaxKeyword
+		}
+		if class == "" {
+			continue
+		}
+		if m[0] > plain {
+			tokens = append(tokens, SyntaxToken{Text: line[plain:m[0]]})
+		}
+		tokens = append(tokens, SyntaxToken{Text: line[m[0]:m[1]], Class: class})
+		plain = m[1]
+	}
+	if plain < len(line) {
+		tokens = append(tokens, SyntaxToken{Text: line[plain:]})
+	}
+	return tokens
+}
+
+// syntaxTokens highlights line with h, falling back to a single plain
+// token when there is no highlighter or its tokens do not add up to line.
+func syntaxTokens(h SyntaxHighlighter, language, line string) []SyntaxToken {
+	plain := []SyntaxToken{{Text: line}}
+	if h == nil {
+		return plain
+	}
+	tokens := h.Highlight(language, line)
+	n := 0
+	for _, token := range tokens {
+		if !strings.HasPrefix(line[n:], token.Text) {
+			return plain
+		}
+		n += len(token.Text)
+	}
+	if n != len(line) {
+		return plain
+	}
+	return tokens
+}
@@ -0,0 +1,124 @@
+package diff2html
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBasicHighlighter_Highlight(t *testing.T) {
+	tests := []struct {
+		language string
+		line     string
+		want     []SyntaxToken
+	}{
+		{
+			language: "go",
+			line:     `	return "a", 42 // done`,
+			want: []SyntaxToken{
+				{Text: "\t"},
+				{Text: "return", Class: SyntaxKeyword},
+				{Text: " "},
+				{Text: `"a"`, Class: SyntaxString},
+				{Text: ", "},
+				{Text: "42", Class: SyntaxNumber},
+				{Text: " "},
+				{Text: "// done", Class: SyntaxComment},
+			},
+		},
+		{
+			language: "python",
+			line:     "def f(): # x1",
+			want: []SyntaxToken{
+				{Text: "def", Class: SyntaxKeyword},
+				{Text: " f(): "},
+				{Text: "# x1", Class: SyntaxComment},
+			},
+		},
+		{
+			language: "go",
+			line:     `s := "unterminated`,
+			want: []SyntaxToken{
+				{Text: "s := "},
+				{Text: `"unterminated`, Class: SyntaxString},
+			},
+		},
+		{
+			language: "unknown",
+			line:     "return 1",
+			want:     []SyntaxToken{{Text: "return 1"}},
+		},
+	}
+	for _, tt := range tests {
+		got := BasicHighlighter{}.Highlight(tt.language, tt.line)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Highlight(%q, %q) = %q, want %q", tt.language, tt.line, got, tt.want)
+		}
+	}
+}
+
+type brokenHighlighter struct{}
+
+func (brokenHighlighter) Highlight(language, line string) []SyntaxToken {
+	return []SyntaxToken{{Text: "<not the line>", Class: "x"}}
+}
+
+func Test_syntaxTokens(t *testing.T) {
+	want := []SyntaxToken{{Text: "a < b"}}
+	if got := syntaxTokens(nil, "go", "a < b"); !reflect.DeepEqual(got, want) {
+		t.Errorf("nil highlighter: got %q", got)
+	}
+	if got := syntaxTokens(brokenHighlighter{}, "go", "a < b"); !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatched tokens: got %q", got)
+	}
+}
+
+func Test_diffHighlight_syntax(t *testing.T) {
+	highlight := diffHighlight("-return a < b", "+return a <= b", false, DiffStyleChar, IgnoreWhitespaceNone, BasicHighlighter{}, "go")
+	want := `<span class="d2h-syntax-keyword">return</span> a &lt; b`
+	if string(highlight.First.Line) != want {
+		t.Errorf("first: got %s, want %s", highlight.First.Line, want)
+	}
+	want = `<span class="d2h-syntax-keyword">return</span> a &lt;<ins>=</ins> b`
+	if string(highlight.Second.Line) != want {
+		t.Errorf("second: got %s, want %s", highlight.Second.Line, want)
+	}
+
+	// A keyword that is partly changed is split around the ins element.
+	highlight = diffHighlight("-fun x", "+func x", false, DiffStyleChar, IgnoreWhitespaceNone, BasicHighlighter{}, "go")
+	want = `<span class="d2h-syntax-keyword">fun</span><ins><span class="d2h-syntax-keyword">c</span></ins> x`
+	if string(highlight.Second.Line) != want {
+		t.Errorf("second: got %s, want %s", highlight.Second.Line, want)
+	}
+}
+
+func TestRender_syntaxHighlighter(t *testing.T) {
+	input := "--- a/sample.go\n" +
+		"+++ b/sample.go\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		" package sample\n" +
+		"-var a = \"<b>\"\n" +
+		"+var b = \"<b>\"\n"
+	files, err := Parse(input, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []OutputFormat{SideBySide, LineByLine} {
+		html, err := Render(files, RenderConfig{OutputFormat: format, SyntaxHighlighter: BasicHighlighter{}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			`<span class="d2h-syntax-keyword">package</span> sample`,
+			`<span class="d2h-syntax-string">&#34;&lt;b&gt;&#34;</span>`,
+		} {
+			if !strings.Contains(html, want) {
+				t.Errorf("%s: missing %s", format, want)
+			}
+		}
+		if strings.Contains(html, "<b>") {
+			t.Errorf("%s: content is not escaped", format)
+		}
+	}
+}
@@ -1,6 +1,78 @@
 package diff2html
 
 const (
+	annotation = `<tr class="d2h-annotation{{if .Placeholder}} d2h-annotation-placeholder{{end}}">
+    <td class="d2h-annotation-cell" colspan="2">
+        <div class="d2h-annotation-box"{{if .Placeholder}} aria-hidden="true"{{end}}>
+            <div class="d2h-annotation-header">
+                <span class="d2h-annotation-author">{{.Author}}</span>
+                {{- if .Time}}
+                <time datetime="{{.Datetime}}">{{.Time}}</time>
+                {{- end}}
+            </div>
+            <div class="d2h-annotation-body">{{.Body}}</div>
+        </div>
+    </td>
+</tr>`
+
+	collapseOpen = `<tr class="d2h-expand" data-d2h-collapse="{{.ID}}">
+    <td class="{{.LineClass}} {{.Type}}"></td>
+    <td class="{{.Type}}">
+        <div class="{{.ContentClass}} {{.Type}}">⋯ {{if .Script}}show {{end}}{{.Hidden}} hidden lines</div>
+    </td>
+</tr>
+</tbody>
+<tbody class="d2h-diff-tbody d2h-collapsed" data-d2h-collapse="{{.ID}}">`
+
+	collapseClose = `</tbody>
+<tbody class="d2h-diff-tbody">`
+
+	collapseScript = `
+<script>
+(function () {
+  if (window.d2hCollapse) {
+    return;
+  }
+  window.d2hCollapse = true;
+  document.addEventListener("click", function (event) {
+    var row = event.target.closest && event.target.closest("tr.d2h-expand");
+    if (!row) {
+      return;
+    }
+    var file = row.closest(".d2h-file-wrapper");
+    var id = row.getAttribute("data-d2h-collapse");
+    file.querySelectorAll('[data-d2h-collapse="' + id + '"]').forEach(function (el) {
+      if (el.classList.contains("d2h-expand")) {
+        el.parentNode.removeChild(el);
+      } else {
+        el.classList.remove("d2h-collapsed");
+      }
+    });
+  });
+})();
+</script>`
+
+	documentHeader = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>{{.Title}}</title>
+  <style>
+{{.CSS}}
+  </style>
+</head>
+<body>
+`
+
+	documentFooter = `
+</body>
+</html>
+`
+
+	expandAll = `<details class="d2h-expand-all">
+        <summary>Show {{.Hidden}} hidden lines</summary>
+    </details>`
+
 	genericColumnLineNumber = `<tr>
     <td class="{{.LineClass}} {{.Type}}"></td>
     <td class="{{.Type}}">
@@ -24,7 +96,7 @@ const (
 
 	genericLine = `<tr>
     <td class="{{.LineClass}} {{.Type}}">
-        {{.LineNumberStr}}
+        {{.LineNumber}}
     </td>
     <td class="{{.Type}}">
         <div class="{{.ContentClass}} {{.Type}}">
@@ -34,18 +106,49 @@ const (
     </td>
 </tr>`
 
-	genericWrapper = `<div class="d2h-wrapper">
-    {{.Content}}
+	genericWrapperOpen = `<div class="d2h-wrapper">
+    `
+
+	genericWrapperClose = `
 </div>`
 
 	iconFile = `<svg aria-hidden="true" class="d2h-icon" height="16" version="1.1" viewBox="0 0 12 16" width="12">
     <path d="M6 5H2v-1h4v1zM2 8h7v-1H2v1z m0 2h7v-1H2v1z m0 2h7v-1H2v1z m10-7.5v9.5c0 0.55-0.45 1-1 1H1c-0.55 0-1-0.45-1-1V2c0-0.55 0.45-1 1-1h7.5l3.5 3.5z m-1 0.5L8 2H1v12h10V5z"></path>
 </svg>`
 
+	lineByLineFileDiffOpen = `<div id="{{.FileHTMLID}}" class="d2h-file-wrapper" data-lang="{{.Language}}">
+    <div class="d2h-file-header">
+        {{.FilePath}}
+    </div>
+    {{.ExpandAll}}
+    <div class="d2h-file-diff">
+        <div class="d2h-code-wrapper">
+            <table class="d2h-diff-table">
+                <tbody class="d2h-diff-tbody">
+                `
+
+	lineByLineFileDiffClose = `
+                </tbody>
+            </table>
+        </div>
+    </div>
+</div>`
+
+	lineLink = `<a id="{{.ID}}" class="d2h-line-link" href="{{.Href}}">{{.Number}}</a>`
+
+	lineByLineNumbers = `<div class="line-num1">{{.OldNumber}}</div>
+<div class="line-num2">{{.NewNumber}}</div>`
+
+	crDeleted  = `<del class="d2h-cr" title="Carriage return">␍</del>`
+	crInserted = `<ins class="d2h-cr" title="Carriage return">␍</ins>`
+
+	noNewline = `<span class="d2h-no-newline" title="No newline at end of file">⊘</span>`
+
 	sideBySideFileDiff = `<div id="{{.FileHTMLID}}" class="d2h-file-wrapper" data-lang="{{.Language}}">
     <div class="d2h-file-header">
         {{.FilePath}}
     </div>
+    {{.ExpandAll}}
     <div class="d2h-file-diff">
         <div class="d2h-file-side-diff">
             <div class="d2h-code-wrapper">
@@ -68,6 +171,32 @@ const (
     </div>
 </div>`
 
+	summary = `<div class="d2h-file-list-wrapper">
+    <div class="d2h-file-list-header">
+        <span class="d2h-file-list-title">{{.Title}}</span>
+    </div>
+    <ol class="d2h-file-list">
+    {{- range .Rows}}
+        <li class="d2h-file-list-line">
+            <span class="d2h-file-name-wrapper">
+                <span class="d2h-icon-wrapper">{{$.FileIcon}}</span>
+                <a href="#{{.ID}}" class="d2h-file-name">{{.Name}}</a>
+                <span class="d2h-file-stats">
+                    {{- if .Binary}}
+                    <span class="d2h-lines-binary">binary</span>
+                    {{- else}}
+                    <span class="d2h-lines-added">+{{.Added}}</span>
+                    <span class="d2h-lines-deleted">-{{.Deleted}}</span>
+                    {{- end}}
+                    <span class="d2h-diffstat">{{range .Bar}}<span class="d2h-diffstat-{{.}}"></span>{{end}}</span>
+                </span>
+            </span>
+        </li>
+    {{- end}}
+    </ol>
+</div>
+`
+
 	tagFileAdded = `<span class="d2h-tag d2h-added d2h-added-tag">ADDED</span>`
 
 	tagFileChanged = `<span class="d2h-tag d2h-changed d2h-changed-tag">CHANGED</span>`
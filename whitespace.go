@@ -0,0 +1,168 @@
+package diff2html
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// IgnoreWhitespace selects the whitespace differences that do not make
+// a pair of lines changed, like the whitespace options of git diff.
+type IgnoreWhitespace string
+
+const (
+	// IgnoreWhitespaceNone highlights every difference.
+	IgnoreWhitespaceNone IgnoreWhitespace = "none"
+	// IgnoreWhitespaceAll ignores all whitespace, like git diff -w.
+	IgnoreWhitespaceAll IgnoreWhitespace = "all"
+	// IgnoreWhitespaceChange ignores changes in the amount of whitespace
+	// and whitespace at the end of lines, like git diff -b.
+	IgnoreWhitespaceChange IgnoreWhitespace = "change"
+	// IgnoreWhitespaceTrailing ignores whitespace at the end of lines,
+	// like git diff --ignore-space-at-eol.
+	IgnoreWhitespaceTrailing IgnoreWhitespace = "trailing"
+	// IgnoreWhitespaceCRAtEOL ignores a carriage return at the end of
+	// lines, like git diff --ignore-cr-at-eol.
+	IgnoreWhitespaceCRAtEOL IgnoreWhitespace = "cr-at-eol"
+)
+
+func (mode IgnoreWhitespace) ignores() bool {
+	return mode != "" && mode != IgnoreWhitespaceNone
+}
+
+// normalizeWhitespace removes or shortens the whitespace of line that mode
+// ignores. index gives, for every byte of line, the position in runes of
+// the character of the result it became, or -1 for removed bytes.
+func normalizeWhitespace(line string, mode IgnoreWhitespace) (normal string, index []int) {
+	end := len(line)
+	switch mode {
+	case IgnoreWhitespaceChange, IgnoreWhitespaceTrailing:
+		end = len(strings.TrimRightFunc(line, unicode.IsSpace))
+	case IgnoreWhitespaceCRAtEOL:
+		// Line.CRLF holds the carriage return of a CRLF line; only a last
+		// line without a newline keeps it in its content.
+		end = len(strings.TrimSuffix(line, "\r"))
+	}
+
+	buf := &strings.Builder{}
+	index = make([]int, len(line))
+	runes := 0
+	space := -1 // the character a run of whitespace became
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		at := -1
+		switch {
+		case i >= end:
+		case unicode.IsSpace(r) && mode == IgnoreWhitespaceAll:
+		case unicode.IsSpace(r) && mode == IgnoreWhitespaceChange:
+			if space < 0 {
+				space = runes
+				buf.WriteByte(' ')
+				runes++
+			}
+			at = space
+		default:
+			space = -1
+			at = runes
+			buf.WriteString(line[i : i+size])
+			runes++
+		}
+		for j := i; j < i+size; j++ {
+			index[j] = at
+		}
+		i += size
+	}
+	return buf.String(), index
+}
+
+// whitespaceOnly reports whether two lines differ only in whitespace that
+// mode ignores.
+func whitespaceOnly(line1, line2 string, mode IgnoreWhitespace) bool {
+	if !mode.ignores() {
+		return false
+	}
+	normal1, _ := normalizeWhitespace(line1, mode)
+	normal2, _ := normalizeWhitespace(line2, mode)
+	return normal1 == normal2
+}
+
+// showsAsContext reports whether a paired deleted and inserted line are
+// shown as a single unchanged line, with WhitespaceAsContext.
+func showsAsContext(conf RenderConfig, file *File, oldLine, newLine *Line) bool {
+	if !conf.WhitespaceAsContext || file.IsCombined || oldLine.NoNewlineAtEOF != newLine.NoNewlineAtEOF {
+		return false
+	}
+	_, oldContent := separatePrefix(file.IsCombined, oldLine.Content)
+	_, newContent := separatePrefix(file.IsCombined, newLine.Content)
+	return whitespaceOnly(oldContent, newContent, conf.IgnoreWhitespace)
+}
+
+// whitespaceDiff diffs two lines without the whitespace mode ignores, and
+// splits each line into the parts shown as unchanged, deleted or
+// inserted. Ignored whitespace is unchanged, unless it lies inside a
+// change.
+func whitespaceDiff(line1, line2 string, mode IgnoreWhitespace, style DiffStyle) (first, second []diffmatchpatch.Diff) {
+	normal1, index1 := normalizeWhitespace(line1, mode)
+	normal2, index2 := normalizeWhitespace(line2, mode)
+
+	differ := diffmatchpatch.New()
+	diffs := differ.DiffMain(normal1, normal2, true)
+	if style != DiffStyleChar {
+		diffs = differ.DiffCleanupSemantic(diffs)
+	}
+
+	ops1 := []diffmatchpatch.Operation{}
+	ops2 := []diffmatchpatch.Operation{}
+	for _, part := range diffs {
+		for n := utf8.RuneCountInString(part.Text); n > 0; n-- {
+			if part.Type != diffmatchpatch.DiffInsert {
+				ops1 = append(ops1, part.Type)
+			}
+			if part.Type != diffmatchpatch.DiffDelete {
+				ops2 = append(ops2, part.Type)
+			}
+		}
+	}
+	return whitespaceParts(line1, index1, ops1), whitespaceParts(line2, index2, ops2)
+}
+
+// whitespaceParts groups the bytes of line by the operation of the
+// character they became.
+func whitespaceParts(line string, index []int, ops []diffmatchpatch.Operation) []diffmatchpatch.Diff {
+	byteOps := make([]diffmatchpatch.Operation, len(line))
+	for i, at := range index {
+		byteOps[i] = diffmatchpatch.DiffEqual
+		if at >= 0 && at < len(ops) {
+			byteOps[i] = ops[at]
+		}
+	}
+	// Removed whitespace between two parts of the same change joins them.
+	for i := 0; i < len(line); {
+		if index[i] >= 0 {
+			i++
+			continue
+		}
+		j := i
+		for j < len(line) && index[j] < 0 {
+			j++
+		}
+		if i > 0 && j < len(line) && byteOps[i-1] == byteOps[j] {
+			for k := i; k < j; k++ {
+				byteOps[k] = byteOps[j]
+			}
+		}
+		i = j
+	}
+
+	parts := []diffmatchpatch.Diff{}
+	start := 0
+	for i := 1; i <= len(line); i++ {
+		if i == len(line) || byteOps[i] != byteOps[start] {
+			parts = append(parts, diffmatchpatch.Diff{Type: byteOps[start], Text: line[start:i]})
+			start = i
+		}
+	}
+	return parts
+}
@@ -0,0 +1,131 @@
+package diff2html
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_normalizeWhitespace(t *testing.T) {
+	tests := []struct {
+		mode  IgnoreWhitespace
+		line  string
+		want  string
+		index []int
+	}{
+		{IgnoreWhitespaceAll, " a \tb ", "ab", []int{-1, 0, -1, -1, 1, -1}},
+		{IgnoreWhitespaceChange, "a \tb  ", "a b", []int{0, 1, 1, 2, -1, -1}},
+		{IgnoreWhitespaceTrailing, " a b \r", " a b", []int{0, 1, 2, 3, -1, -1}},
+		{IgnoreWhitespaceCRAtEOL, "a \r", "a ", []int{0, 1, -1}},
+		{IgnoreWhitespaceChange, "é  x", "é x", []int{0, 0, 1, 1, 2}},
+	}
+	for _, tt := range tests {
+		got, index := normalizeWhitespace(tt.line, tt.mode)
+		if got != tt.want {
+			t.Errorf("%s %q: got %q, want %q", tt.mode, tt.line, got, tt.want)
+		}
+		if len(index) != len(tt.index) {
+			t.Errorf("%s %q: got index %v, want %v", tt.mode, tt.line, index, tt.index)
+			continue
+		}
+		for i := range index {
+			if index[i] != tt.index[i] {
+				t.Errorf("%s %q: got index %v, want %v", tt.mode, tt.line, index, tt.index)
+				break
+			}
+		}
+	}
+}
+
+func Test_whitespaceOnly(t *testing.T) {
+	tests := []struct {
+		mode   IgnoreWhitespace
+		a, b   string
+		expect bool
+	}{
+		{IgnoreWhitespaceNone, "a b", "a  b", false},
+		{IgnoreWhitespaceAll, "a b", "ab", true},
+		{IgnoreWhitespaceChange, "a b", "ab", false},
+		{IgnoreWhitespaceChange, "\tif a {", "    if  a {  ", true},
+		{IgnoreWhitespaceTrailing, "  x", "x", false},
+		{IgnoreWhitespaceTrailing, "x", "x \t", true},
+		{IgnoreWhitespaceCRAtEOL, "x ", "x", false},
+		{IgnoreWhitespaceCRAtEOL, "x\r", "x", true},
+		{IgnoreWhitespaceAll, "x", "x", true},
+	}
+	for _, tt := range tests {
+		if got := whitespaceOnly(tt.a, tt.b, tt.mode); got != tt.expect {
+			t.Errorf("%s %q %q: got %v", tt.mode, tt.a, tt.b, got)
+		}
+	}
+}
+
+func Test_diffHighlight_whitespace(t *testing.T) {
+	tests := []struct {
+		mode          IgnoreWhitespace
+		a, b          string
+		first, second string
+	}{
+		{IgnoreWhitespaceNone, "-\tx := 1", "+    x := 1", "<del>\t</del>x := 1", "<ins>    </ins>x := 1"},
+		{IgnoreWhitespaceChange, "-\tx := 1", "+    x := 1", "\tx := 1", "    x := 1"},
+		{IgnoreWhitespaceChange, "-\tx := 1", "+    x := 2", "\tx := <del>1</del>", "    x := <ins>2</ins>"},
+		{IgnoreWhitespaceChange, "-ab", "+a b", "ab", "a<ins> </ins>b"},
+		{IgnoreWhitespaceAll, "-ab", "+a b", "ab", "a b"},
+		{IgnoreWhitespaceAll, "-keep xy z", "+keep q", "keep <del>xy z</del>", "keep <ins>q</ins>"},
+		{IgnoreWhitespaceTrailing, "-x = 1  ", "+x = 2", "x = <del>1</del>  ", "x = <ins>2</ins>"},
+	}
+	for _, tt := range tests {
+		h := diffHighlight(tt.a, tt.b, false, DiffStyleChar, tt.mode, nil, "")
+		if string(h.First.Line) != tt.first || string(h.Second.Line) != tt.second {
+			t.Errorf("%s %q %q: got %q %q, want %q %q", tt.mode, tt.a, tt.b, h.First.Line, h.Second.Line, tt.first, tt.second)
+		}
+	}
+}
+
+func TestRender_whitespaceAsContext(t *testing.T) {
+	input := "diff --git a/main.go b/main.go\n" +
+		"index 1a2b3c4..5d6e7f8 100644\n" +
+		"--- a/main.go\n" +
+		"+++ b/main.go\n" +
+		"@@ -1,3 +1,3 @@\n" +
+		"-func a() {\n" +
+		"-\treturn 1\n" +
+		"-}\n" +
+		"+func a() {\n" +
+		"+    return 2\n" +
+		"+}  \n"
+	files, err := Parse(input, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []OutputFormat{SideBySide, LineByLine} {
+		conf := RenderConfig{OutputFormat: format, IgnoreWhitespace: IgnoreWhitespaceChange, WhitespaceAsContext: true}
+		html, err := Render(files, conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Only the return lines are still a change.
+		if got := strings.Count(html, `class="d2h-del d2h-change"`); got != 1 {
+			t.Errorf("%s: expected 1 changed deletion, got %d:\n%s", format, got, html)
+		}
+		if got := strings.Count(html, `class="d2h-ins d2h-change"`); got != 1 {
+			t.Errorf("%s: expected 1 changed insertion, got %d", format, got)
+		}
+
+		conf.WhitespaceAsContext = false
+		html, err = Render(files, conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Count(html, `class="d2h-del d2h-change"`); got != 3 {
+			t.Errorf("%s: expected 3 changed deletions, got %d", format, got)
+		}
+		if strings.Contains(html, "<ins>  </ins>") {
+			t.Errorf("%s: trailing whitespace should not be highlighted", format)
+		}
+	}
+
+	if _, err := Render(files, RenderConfig{IgnoreWhitespace: "some"}); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}